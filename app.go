@@ -3,10 +3,12 @@ package main
 import (
 	"fmt"
 	"github.com/skhatri/shores/pkg/applog"
+	"github.com/skhatri/shores/pkg/environment"
 	"github.com/skhatri/shores/pkg/model"
 	templates "github.com/skhatri/shores/pkg/template"
 	"log"
 	"os"
+	"strings"
 	"time"
 )
 
@@ -22,16 +24,71 @@ func main() {
 		Created:   now.Format(time.RFC3339),
 		ChangeRef: "CRQ000019921",
 		Output:    "../shores-helm/charts",
+		Renderer:  os.Getenv("SHORES_RENDERER"),
+		Mode:      os.Getenv("SHORES_MODE"),
+		ChartRepo: os.Getenv("SHORES_CHART_REPO"),
+		Matrix:    os.Getenv("SHORES_MATRIX") == "true",
+	}
+	if output := os.Getenv("SHORES_OUTPUT"); output != "" {
+		task.Output = output
+	}
+	if task.Output == model.StdoutOutput {
+		applog.Output = os.Stderr
 	}
 	productSet, err := model.NewProductSetFromFile(release, "default")
 	if err != nil {
 		log.Fatalf("error processing product set file: %v", err)
 	}
-	dSummary, tErr := templates.Run(productSet, task)
+	ctx, cErr := templates.ProcessContext()
+	if cErr != nil {
+		log.Fatalf("error resolving the environment: %v", cErr)
+	}
+	if len(os.Args) > 2 && os.Args[1] == "resources" && os.Args[2] == "explain" {
+		explainResources(productSet, task, ctx)
+		return
+	}
+	var dSummary *model.DeploymentSummary
+	var tErr error
+	if task.Matrix {
+		dSummary, tErr = templates.RunMatrix(productSet, task)
+	} else {
+		dSummary, tErr = templates.Run(productSet, task, ctx)
+	}
 	if tErr != nil {
 		log.Fatalf("error running template: %v", tErr)
 	}
 	for _, item := range dSummary.Items {
-		applog.Tag("summary").Info("generated for %s at %s", item.Name, item.Path)
+		applog.Tag("summary").WithAttribute("qos", item.Qos).Info("generated for %s at %s", item.Name, item.Path)
+	}
+}
+
+func explainResources(productSet *model.ProductSet, task model.Task, ctx environment.Context) {
+	deployables, err := templates.Prepare(productSet, task, ctx)
+	if err != nil {
+		log.Fatalf("error preparing apps: %v", err)
+	}
+	for _, deployable := range deployables {
+		fmt.Printf("%s (qos: %s)\n", deployable.Artifact.Name, deployable.QosClass)
+		for _, variant := range deployable.ResourceVariants {
+			fmt.Printf("  %s: %s\n", variant.Name, variant.Variant)
+		}
+		if deployable.Resources != nil {
+			fmt.Printf("  requests: %s\n", describeValue(deployable.Resources.Requests))
+			fmt.Printf("  limits: %s\n", describeValue(deployable.Resources.Limits))
+		}
+	}
+}
+
+func describeValue(value *model.ResourceValue) string {
+	if value == nil {
+		return "-"
+	}
+	parts := make([]string, 0)
+	if value.Cpu != nil {
+		parts = append(parts, fmt.Sprintf("cpu=%s", *value.Cpu))
+	}
+	if value.Memory != nil {
+		parts = append(parts, fmt.Sprintf("memory=%s", *value.Memory))
 	}
+	return strings.Join(parts, " ")
 }
@@ -4,12 +4,16 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"time"
 )
 
 var LogLevel = os.Getenv("LOG_LEVEL")
 
+// Output receives the log lines, it is moved to stderr when stdout carries generated manifests
+var Output io.Writer = os.Stdout
+
 type LogBuilder interface {
 	WithAttribute(key string, value interface{}) LogBuilder
 	Info(format string, args ...interface{})
@@ -34,7 +38,7 @@ func (logBuilder *_builder) log(level string, format string, args ...interface{}
 	logBuilder.attributes["message"] = fmt.Sprintf(format, args[:]...)
 	str := bytes.Buffer{}
 	json.NewEncoder(&str).Encode(logBuilder.attributes)
-	fmt.Print(str.String())
+	fmt.Fprint(Output, str.String())
 }
 
 func (logBuilder *_builder) WithAttribute(key string, value interface{}) LogBuilder {
@@ -0,0 +1,67 @@
+package chartrepo
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"sort"
+	"time"
+)
+
+// File is a file of a chart, the path is relative to the chart directory
+type File struct {
+	Path    string
+	Content []byte
+}
+
+// archiveTime is stamped on every entry so packaging the same chart twice gives the same bytes
+var archiveTime = time.Unix(0, 0).UTC()
+
+// Archive packages the chart files under a directory named after the chart, entries are sorted and carry fixed metadata
+func Archive(name string, files []File) ([]byte, error) {
+	sorted := append([]File{}, files...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Path < sorted[j].Path
+	})
+	out := bytes.Buffer{}
+	zipper := gzip.NewWriter(&out)
+	zipper.ModTime = archiveTime
+	tarball := tar.NewWriter(zipper)
+	for _, file := range sorted {
+		header := &tar.Header{
+			Name:     path.Join(name, file.Path),
+			Mode:     0644,
+			Size:     int64(len(file.Content)),
+			ModTime:  archiveTime,
+			Typeflag: tar.TypeReg,
+			Format:   tar.FormatPAX,
+		}
+		if err := tarball.WriteHeader(header); err != nil {
+			return nil, err
+		}
+		if _, err := tarball.Write(file.Content); err != nil {
+			return nil, err
+		}
+	}
+	if err := tarball.Close(); err != nil {
+		return nil, err
+	}
+	if err := zipper.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// ArchiveName is the file name helm gives a packaged chart
+func ArchiveName(name string, version string) string {
+	return fmt.Sprintf("%s-%s.tgz", name, version)
+}
+
+func Digest(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,77 @@
+package chartrepo
+
+import (
+	"errors"
+	"fmt"
+	"github.com/skhatri/shores/pkg/functions"
+	"github.com/skhatri/shores/pkg/k8s"
+	"gopkg.in/yaml.v2"
+	"os"
+	"sort"
+)
+
+// Index is the index.yaml of a helm chart repository
+type Index struct {
+	ApiVersion string             `json:"apiVersion" yaml:"apiVersion"`
+	Entries    map[string][]Entry `json:"entries" yaml:"entries"`
+	Generated  string             `json:"generated" yaml:"generated"`
+}
+
+type Entry struct {
+	k8s.Chart `json:",inline" yaml:",inline"`
+	Created   string   `json:"created" yaml:"created"`
+	Digest    string   `json:"digest" yaml:"digest"`
+	Urls      []string `json:"urls" yaml:"urls"`
+}
+
+// LoadIndex reads the repository index, a missing index gives an empty one
+func LoadIndex(file string) (*Index, error) {
+	index := &Index{ApiVersion: "v1", Entries: make(map[string][]Entry, 0)}
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		return index, nil
+	}
+	if err := functions.UnmarshalFile(file, index); err != nil {
+		return nil, err
+	}
+	if index.Entries == nil {
+		index.Entries = make(map[string][]Entry, 0)
+	}
+	return index, nil
+}
+
+// Add records a packaged chart, an unchanged archive keeps the creation time it was first indexed with.
+// A published version is immutable, different content under the same name and version is refused
+func (idx *Index) Add(chart k8s.Chart, archive string, digest string, created string) error {
+	entries := make([]Entry, 0)
+	for _, entry := range idx.Entries[chart.Name] {
+		if entry.Version != chart.Version {
+			entries = append(entries, entry)
+			continue
+		}
+		if entry.Digest != digest {
+			return errors.New(fmt.Sprintf("chart %s version %s is already published with digest %s, the new archive has digest %s",
+				chart.Name, chart.Version, entry.Digest, digest))
+		}
+		created = entry.Created
+	}
+	entries = append(entries, Entry{
+		Chart:   chart,
+		Created: created,
+		Digest:  digest,
+		Urls:    []string{archive},
+	})
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Created > entries[j].Created
+	})
+	idx.Entries[chart.Name] = entries
+	return nil
+}
+
+func (idx *Index) Write(file string, generated string) error {
+	idx.Generated = generated
+	content, err := yaml.Marshal(idx)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, content, 0644)
+}
@@ -0,0 +1,37 @@
+package customtemplate
+
+import "strings"
+
+type Template struct {
+	Kind     string       `json:"kind" yaml:"kind"`
+	Metadata Metadata     `json:"metadata" yaml:"metadata"`
+	Spec     TemplateSpec `json:"spec" yaml:"spec"`
+}
+
+type Metadata struct {
+	Name string `json:"name" yaml:"name"`
+}
+
+// TemplateSpec points at a go template file, a name of a built-in template replaces it, any other name adds a file.
+// A template with enabled: false is skipped, which lets samples ship without changing the output
+type TemplateSpec struct {
+	File    string   `json:"file" yaml:"file"`
+	Output  string   `json:"output" yaml:"output"`
+	Kinds   []string `json:"kinds" yaml:"kinds"`
+	Enabled *bool    `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	Name    string   `json:"-" yaml:"-"`
+	Text    string   `json:"-" yaml:"-"`
+}
+
+// AppliesTo reports whether the template is used for the app kind, no kinds means every kind
+func (ts *TemplateSpec) AppliesTo(kind string) bool {
+	if len(ts.Kinds) == 0 {
+		return true
+	}
+	for _, k := range ts.Kinds {
+		if strings.EqualFold(k, kind) {
+			return true
+		}
+	}
+	return false
+}
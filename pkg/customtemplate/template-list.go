@@ -0,0 +1,56 @@
+package customtemplate
+
+import (
+	"fmt"
+	"github.com/skhatri/shores/pkg/applog"
+	"github.com/skhatri/shores/pkg/functions"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+func LoadTemplates(files []string) map[string]TemplateSpec {
+	errors := make([]string, 0)
+	templates := make(map[string]TemplateSpec, 0)
+	sources := make(map[string]string, 0)
+	for _, file := range files {
+		templateKind := Template{}
+		err := functions.UnmarshalFile(file, &templateKind)
+		if err != nil {
+			errors = append(errors, err.Error())
+			continue
+		}
+		if templateKind.Kind != "Template" {
+			continue
+		}
+		spec := templateKind.Spec
+		spec.Name = templateKind.Metadata.Name
+		if spec.Enabled != nil && !*spec.Enabled {
+			continue
+		}
+		if previous, exists := sources[spec.Name]; exists {
+			errors = append(errors, fmt.Sprintf("file: [%s], error: [template %s is already defined in %s]", file, spec.Name, previous))
+			continue
+		}
+		if spec.File == "" {
+			errors = append(errors, fmt.Sprintf("file: [%s], error: [template %s has no file]", file, spec.Name))
+			continue
+		}
+		templateFile := filepath.Join(filepath.Dir(file), spec.File)
+		content, rerr := ioutil.ReadFile(templateFile)
+		if rerr != nil {
+			errors = append(errors, fmt.Sprintf("file: [%s], error: [%v]", file, rerr))
+			continue
+		}
+		spec.Text = string(content)
+		if spec.Output == "" {
+			spec.Output = "%s-" + strings.TrimSuffix(filepath.Base(spec.File), filepath.Ext(spec.File)) + ".yaml"
+		}
+		templates[spec.Name] = spec
+		sources[spec.Name] = file
+	}
+	if len(errors) > 0 {
+		applog.Tag("load-templates").Error("errors while loading templates: %s", errors)
+	}
+	return templates
+}
@@ -0,0 +1,71 @@
+package datamap
+
+import (
+	"errors"
+	"fmt"
+	"github.com/skhatri/shores/pkg/applog"
+	"github.com/skhatri/shores/pkg/functions"
+	"unicode"
+)
+
+const defaultKey = "default"
+
+func LoadDataMaps(files []string) map[string]map[string]string {
+	errors := make([]string, 0)
+	dataMaps := make(map[string]map[string]string, 0)
+	for _, file := range files {
+		dataMap := DataMap{}
+		err := functions.UnmarshalFile(file, &dataMap)
+		if err != nil {
+			errors = append(errors, err.Error())
+			continue
+		}
+		if dataMap.Kind != "DataMap" {
+			continue
+		}
+		data := make(map[string]string, 0)
+		for k, v := range dataMap.Spec.Data {
+			data[k] = v
+		}
+		dataMaps[dataMap.Metadata.Name] = data
+	}
+	if len(errors) > 0 {
+		applog.Tag("load-datamaps").Error("errors while loading data maps: %s", errors)
+	}
+	return dataMaps
+}
+
+// Lookup finds key in the named data map, falling back to the map's default entry
+func Lookup(dataMaps map[string]map[string]string, name string, key string) (string, error) {
+	data, ok := dataMaps[name]
+	if !ok {
+		return "", errors.New(fmt.Sprintf("data map [%s] not found", name))
+	}
+	if value, ok := data[key]; ok {
+		return value, nil
+	}
+	if value, ok := data[defaultKey]; ok {
+		return value, nil
+	}
+	return "", errors.New(fmt.Sprintf("data map [%s] has no value for [%s] and no default", name, key))
+}
+
+// Resolve returns literal values as is and looks up tokens such as c1 or m05 in the named data map, a token it cannot find is an error
+func Resolve(dataMaps map[string]map[string]string, name string, value *string) (*string, error) {
+	if value == nil || !IsToken(*value) {
+		return value, nil
+	}
+	resolved, err := Lookup(dataMaps, name, *value)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("could not resolve token [%s] in data map [%s], error: [%v]", *value, name, err))
+	}
+	return &resolved, nil
+}
+
+func IsToken(value string) bool {
+	if value == "" {
+		return false
+	}
+	first := rune(value[0])
+	return !unicode.IsDigit(first) && first != '.'
+}
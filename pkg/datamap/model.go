@@ -0,0 +1,15 @@
+package datamap
+
+type DataMap struct {
+	Kind     string      `json:"kind" yaml:"kind"`
+	Metadata Metadata    `json:"metadata" yaml:"metadata"`
+	Spec     DataMapSpec `json:"spec" yaml:"spec"`
+}
+
+type Metadata struct {
+	Name string `json:"name" yaml:"name"`
+}
+
+type DataMapSpec struct {
+	Data map[string]string `json:"data" yaml:"data"`
+}
@@ -0,0 +1,130 @@
+package dataref
+
+import (
+	"errors"
+	"fmt"
+	"github.com/skhatri/shores/pkg/applog"
+	"github.com/skhatri/shores/pkg/environment"
+	"github.com/skhatri/shores/pkg/functions"
+	"github.com/skhatri/shores/pkg/selector"
+)
+
+const (
+	nameKey           = "name"
+	infrastructureKey = "infrastructure"
+)
+
+// LoadDataRefs resolves every data reference to the entry of the first context candidate it has,
+// so an environment without an entry of its own takes the values of its fallback chain and then of its tier.
+// The attributes of the infrastructure instances the entry points at are added to its values
+func LoadDataRefs(files []string, infrastructureFiles []string, ctx environment.Context) map[string]map[string]string {
+	errors := make([]string, 0)
+	instances := loadInstances(infrastructureFiles, ctx)
+	dataRefs := make(map[string]map[string]string, 0)
+	for _, file := range files {
+		dataRef := DataRef{}
+		err := functions.UnmarshalFile(file, &dataRef)
+		if err != nil {
+			errors = append(errors, err.Error())
+			continue
+		}
+		if dataRef.Kind != "Resource" {
+			continue
+		}
+		entry := candidateEntry(dataRef.Spec.Template, ctx)
+		if entry == nil {
+			continue
+		}
+		name := dataRef.Metadata.Name
+		data := make(map[string]string, 0)
+		if refs, ok := entry[infrastructureKey].([]interface{}); ok {
+			for _, ref := range refs {
+				attributes, found := instances[fmt.Sprint(ref)]
+				if !found {
+					errors = append(errors, fmt.Sprintf("data reference %s points at unknown infrastructure %v", name, ref))
+					continue
+				}
+				for k, v := range attributes {
+					if old, exists := data[k]; exists && old != v {
+						errors = append(errors, fmt.Sprintf("data reference %s has conflicting infrastructure values for %s", name, k))
+					}
+					data[k] = v
+				}
+			}
+		}
+		for k, v := range entry {
+			if k == nameKey || k == infrastructureKey {
+				continue
+			}
+			data[k] = fmt.Sprint(v)
+		}
+		dataRefs[name] = data
+	}
+	if len(errors) > 0 {
+		applog.Tag("load-data-refs").Error("errors while loading data references: %s", errors)
+	}
+	return dataRefs
+}
+
+// loadInstances maps name/instance of every infrastructure instance to its attributes,
+// an infrastructure declared by several documents takes its instances from the most specific one matching the context
+func loadInstances(files []string, ctx environment.Context) map[string]map[string]string {
+	errors := make([]string, 0)
+	chosen := make(map[string][]Instance, 0)
+	ranking := selector.NewRanking("infrastructure", ctx)
+	for _, file := range files {
+		infrastructure := Infrastructure{}
+		err := functions.UnmarshalFile(file, &infrastructure)
+		if err != nil {
+			errors = append(errors, err.Error())
+			continue
+		}
+		if infrastructure.Kind != "Infrastructure" || !ranking.Accept(infrastructure.Metadata.Name, file, infrastructure.Spec.Selector) {
+			continue
+		}
+		chosen[infrastructure.Metadata.Name] = infrastructure.Spec.Template
+	}
+	instances := make(map[string]map[string]string, 0)
+	for name, template := range chosen {
+		for _, instance := range template {
+			if instance.Name == "" {
+				errors = append(errors, fmt.Sprintf("infrastructure %s has an instance without a name", name))
+				continue
+			}
+			attributes := make(map[string]string, 0)
+			for k, v := range instance.Attributes {
+				attributes[k] = fmt.Sprint(v)
+			}
+			instances[fmt.Sprintf("%s/%s", name, instance.Name)] = attributes
+		}
+	}
+	if len(errors) > 0 {
+		applog.Tag("load-infrastructure").Error("errors while loading infrastructure: %s", errors)
+	}
+	return instances
+}
+
+func candidateEntry(entries []map[string]interface{}, ctx environment.Context) map[string]interface{} {
+	for _, candidate := range ctx.Candidates() {
+		for _, entry := range entries {
+			name := fmt.Sprint(entry[nameKey])
+			if name == candidate || (candidate == ctx.EnvName && ctx.IsEnv(name)) {
+				return entry
+			}
+		}
+	}
+	return nil
+}
+
+// Lookup finds the key in the values the named data reference resolved to for the context
+func Lookup(dataRefs map[string]map[string]string, name string, key string) (string, error) {
+	data, ok := dataRefs[name]
+	if !ok {
+		return "", errors.New(fmt.Sprintf("data reference [%s] not found or has no entry for the environment", name))
+	}
+	value, ok := data[key]
+	if !ok {
+		return "", errors.New(fmt.Sprintf("data reference [%s] has no value for [%s]", name, key))
+	}
+	return value, nil
+}
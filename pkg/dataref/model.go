@@ -0,0 +1,36 @@
+package dataref
+
+import "github.com/skhatri/shores/pkg/selector"
+
+type DataRef struct {
+	Kind     string      `json:"kind" yaml:"kind"`
+	Metadata Metadata    `json:"metadata" yaml:"metadata"`
+	Spec     DataRefSpec `json:"spec" yaml:"spec"`
+}
+
+type Metadata struct {
+	Name string `json:"name" yaml:"name"`
+}
+
+// DataRefSpec lists the values of every environment, an entry is named after an environment, one of its aliases or a tier
+type DataRefSpec struct {
+	Template []map[string]interface{} `json:"template" yaml:"template"`
+}
+
+// Infrastructure lists the named instances of a shared service, data references point at one as name/instance.
+// The selector picks the contexts the instances apply to, such as a location with clusters of its own
+type Infrastructure struct {
+	Kind     string             `json:"kind" yaml:"kind"`
+	Metadata Metadata           `json:"metadata" yaml:"metadata"`
+	Spec     InfrastructureSpec `json:"spec" yaml:"spec"`
+}
+
+type InfrastructureSpec struct {
+	Selector selector.Selector `json:"selector" yaml:"selector"`
+	Template []Instance        `json:"template" yaml:"template"`
+}
+
+type Instance struct {
+	Name       string                 `json:"name" yaml:"name"`
+	Attributes map[string]interface{} `json:"attributes" yaml:"attributes"`
+}
@@ -0,0 +1,52 @@
+package envdefinition
+
+import (
+	"fmt"
+	"github.com/skhatri/shores/pkg/applog"
+	"github.com/skhatri/shores/pkg/functions"
+	"github.com/skhatri/shores/pkg/model"
+)
+
+// LoadDefinitions maps every declared environment name to its definition, definitions with an unknown tier or a name claimed twice are skipped
+func LoadDefinitions(files []string) map[string]model.EnvironmentDefinitionSpec {
+	errors := make([]string, 0)
+	definitions := make(map[string]model.EnvironmentDefinitionSpec, 0)
+	claimed := make(map[string]string, 0)
+	for _, file := range files {
+		definition := EnvironmentDefinition{}
+		err := functions.UnmarshalFile(file, &definition)
+		if err != nil {
+			errors = append(errors, err.Error())
+			continue
+		}
+		if definition.Kind != "EnvironmentDefinition" {
+			continue
+		}
+		name := definition.Metadata.Name
+		switch definition.Spec.Tier {
+		case model.TierDev, model.TierTest, model.TierProd:
+		default:
+			errors = append(errors, fmt.Sprintf("environment %s has unknown tier [%s]", name, definition.Spec.Tier))
+			continue
+		}
+		names := append([]string{name}, definition.Spec.Aliases...)
+		duplicate := false
+		for _, n := range names {
+			if owner, exists := claimed[n]; exists {
+				errors = append(errors, fmt.Sprintf("environment name %s of %s is already used by %s", n, name, owner))
+				duplicate = true
+			}
+		}
+		if duplicate {
+			continue
+		}
+		for _, n := range names {
+			claimed[n] = name
+		}
+		definitions[name] = definition.Spec
+	}
+	if len(errors) > 0 {
+		applog.Tag("load-environments").Error("errors while loading environment definitions: %s", errors)
+	}
+	return definitions
+}
@@ -0,0 +1,13 @@
+package envdefinition
+
+import "github.com/skhatri/shores/pkg/model"
+
+type EnvironmentDefinition struct {
+	Kind     string                          `json:"kind" yaml:"kind"`
+	Metadata Metadata                        `json:"metadata" yaml:"metadata"`
+	Spec     model.EnvironmentDefinitionSpec `json:"spec" yaml:"spec"`
+}
+
+type Metadata struct {
+	Name string `json:"name" yaml:"name"`
+}
@@ -1,41 +1,157 @@
 package environment
 
 import (
+	"errors"
+	"fmt"
+	"github.com/skhatri/shores/pkg/model"
 	"os"
+	"sort"
+	"strings"
 )
 
-var envName = os.Getenv("ENV_NAME")
+// Catalog holds the declared locations and environments a context is resolved against
+type Catalog struct {
+	Locations    map[string]model.LocationSpec
+	Environments map[string]model.EnvironmentDefinitionSpec
+}
+
+// Context is the environment a release is rendered for
+type Context struct {
+	EnvName        string   `json:"envName,omitempty" yaml:"envName,omitempty"`
+	Aliases        []string `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+	Tier           string   `json:"tier,omitempty" yaml:"tier,omitempty"`
+	Fallbacks      []string `json:"fallbacks,omitempty" yaml:"fallbacks,omitempty"`
+	Policies       []string `json:"policies,omitempty" yaml:"policies,omitempty"`
+	Location       string   `json:"location,omitempty" yaml:"location,omitempty"`
+	Region         string   `json:"region,omitempty" yaml:"region,omitempty"`
+	Cluster        string   `json:"cluster,omitempty" yaml:"cluster,omitempty"`
+	Provider       string   `json:"provider,omitempty" yaml:"provider,omitempty"`
+	Zones          []string `json:"zones,omitempty" yaml:"zones,omitempty"`
+	RegistryMirror string   `json:"registryMirror,omitempty" yaml:"registryMirror,omitempty"`
+	// Labels are matched by selectors on keys other than the context attributes
+	Labels map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+}
 
-func decodeLocation() string {
-	switch os.Getenv("LOCATION") {
-	case "hk":
-		return "ap-east-1"
-	case "us":
-		return "us-east-1"
-	case "ie":
-		return "eu-west-1"
-	case "ap":
-		return "ap-southeast-1"
+// NewContext resolves the environment name and location code against the catalog, unknown ones are an error.
+// The given labels override the labels declared by the environment and location
+func NewContext(envName string, location string, cluster string, labels map[string]string, catalog Catalog) (Context, error) {
+	ctx := Context{
+		EnvName:  envName,
+		Location: location,
+		Cluster:  cluster,
+		Labels:   make(map[string]string),
 	}
-	return ""
+	if envName != "" && len(catalog.Environments) > 0 {
+		if err := ctx.resolveEnvironment(catalog.Environments); err != nil {
+			return ctx, err
+		}
+	}
+	if location != "" {
+		spec, ok := catalog.Locations[location]
+		if !ok {
+			return ctx, errors.New(fmt.Sprintf("unknown location [%s], expected one of [%s]", location, strings.Join(knownNames(catalog.Locations), ", ")))
+		}
+		ctx.Region = spec.Region
+		ctx.Provider = spec.Provider
+		ctx.Zones = spec.Zones
+		ctx.RegistryMirror = spec.RegistryMirror
+		if ctx.Cluster == "" {
+			ctx.Cluster = spec.DefaultCluster
+		}
+		ctx.addLabels(spec.Labels)
+	}
+	ctx.addLabels(labels)
+	return ctx, nil
 }
 
-var region = decodeLocation()
-var cluster = os.Getenv("CLUSTER")
+func (c *Context) addLabels(labels map[string]string) {
+	for k, v := range labels {
+		c.Labels[k] = v
+	}
+}
 
-func IsProd() bool {
-	return envName == "prod" || envName == "prd"
+func (c *Context) resolveEnvironment(environments map[string]model.EnvironmentDefinitionSpec) error {
+	name := canonicalName(c.EnvName, environments)
+	if name == "" {
+		names := make([]string, 0)
+		for n := range environments {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return errors.New(fmt.Sprintf("unknown environment [%s], expected one of [%s]", c.EnvName, strings.Join(names, ", ")))
+	}
+	definition := environments[name]
+	c.EnvName = name
+	c.Aliases = definition.Aliases
+	c.Tier = definition.Tier
+	c.Policies = definition.Policies
+	c.addLabels(definition.Labels)
+	visited := map[string]bool{name: true}
+	for next := definition.Fallback; next != nil; {
+		fallback := canonicalName(*next, environments)
+		if fallback == "" {
+			return errors.New(fmt.Sprintf("environment [%s] falls back to unknown environment [%s]", name, *next))
+		}
+		if visited[fallback] {
+			return errors.New(fmt.Sprintf("environment [%s] has a fallback cycle through [%s]", name, fallback))
+		}
+		visited[fallback] = true
+		c.Fallbacks = append(c.Fallbacks, fallback)
+		next = environments[fallback].Fallback
+	}
+	return nil
 }
 
-func EnvName() string {
-	return envName
+func canonicalName(name string, environments map[string]model.EnvironmentDefinitionSpec) string {
+	if _, ok := environments[name]; ok {
+		return name
+	}
+	for canonical, definition := range environments {
+		for _, alias := range definition.Aliases {
+			if alias == name {
+				return canonical
+			}
+		}
+	}
+	return ""
+}
+
+func knownNames(locations map[string]model.LocationSpec) []string {
+	known := make([]string, 0)
+	for code := range locations {
+		known = append(known, code)
+	}
+	sort.Strings(known)
+	return known
 }
 
-func Region() string {
-	return region
+// FromProcess reads the context from ENV_NAME, LOCATION and CLUSTER
+func FromProcess(catalog Catalog) (Context, error) {
+	return NewContext(os.Getenv("ENV_NAME"), os.Getenv("LOCATION"), os.Getenv("CLUSTER"), nil, catalog)
 }
 
-func Cluster() string {
-	return cluster
+// IsEnv reports whether the name is the environment or one of its aliases
+func (c Context) IsEnv(name string) bool {
+	if name == c.EnvName {
+		return true
+	}
+	for _, alias := range c.Aliases {
+		if alias == name {
+			return true
+		}
+	}
+	return false
 }
 
+// Candidates lists the names environment keyed values are looked up by, the environment, its fallback chain and then its tier
+func (c Context) Candidates() []string {
+	candidates := make([]string, 0)
+	if c.EnvName != "" {
+		candidates = append(candidates, c.EnvName)
+	}
+	candidates = append(candidates, c.Fallbacks...)
+	if c.Tier != "" && !c.IsEnv(c.Tier) {
+		candidates = append(candidates, c.Tier)
+	}
+	return candidates
+}
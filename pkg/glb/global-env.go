@@ -1,51 +1,102 @@
 package glb
 
 import (
+	"bytes"
+	"fmt"
 	"github.com/skhatri/shores/pkg/applog"
+	"github.com/skhatri/shores/pkg/datamap"
+	"github.com/skhatri/shores/pkg/dataref"
 	"github.com/skhatri/shores/pkg/environment"
 	"github.com/skhatri/shores/pkg/functions"
+	"github.com/skhatri/shores/pkg/selector"
 	"sort"
+	"strings"
+	"text/template"
 )
 
-//TODO substitute using subst map in go template
-func LoadVarsWithSubstitution(files []string, subst map[string]string) map[string]map[string]string {
-	result := loadEnvData(files)
+//LoadVarsWithSubstitution renders env values as go templates with the subst map as data, data maps and environment data references are available through the datamap and dataref functions
+func LoadVarsWithSubstitution(files []string, subst map[string]string, dataMaps map[string]map[string]string, dataRefs map[string]map[string]string, ctx environment.Context) map[string]map[string]string {
+	result, _ := loadEnvData(files, ctx)
+	funcMap := template.FuncMap{
+		"datamap": func(name string, key string) (string, error) {
+			return datamap.Lookup(dataMaps, name, key)
+		},
+		"dataref": func(name string, key string) (string, error) {
+			return dataref.Lookup(dataRefs, name, key)
+		},
+	}
 	data := make(map[string]map[string]string, 0)
 	for k, v := range result {
-		data[k] = v
+		values := make(map[string]string, 0)
+		for attrib, value := range v {
+			values[attrib] = substitute(k, attrib, value, subst, funcMap)
+		}
+		data[k] = values
 	}
 	return data
 }
 
-func LoadVars(files []string) map[string]string {
+func substitute(name string, attrib string, value string, subst map[string]string, funcMap template.FuncMap) string {
+	if !strings.Contains(value, "{{") {
+		return value
+	}
+	tmpl, err := template.New(fmt.Sprintf("%s/%s", name, attrib)).Funcs(funcMap).Option("missingkey=error").Parse(value)
+	if err == nil {
+		buff := bytes.Buffer{}
+		err = tmpl.Execute(&buff, subst)
+		if err == nil {
+			return buff.String()
+		}
+	}
+	applog.Tag("load-vars").WithAttribute("key", attrib).WithAttribute("env_set", name).
+		Error("could not substitute value: %v", err)
+	return value
+}
+
+// LoadVars merges the matching documents, an attribute set by several of them takes the value of the most specific selector
+func LoadVars(files []string, ctx environment.Context) map[string]string {
 	keys := make([]string, 0)
-	result := loadEnvData(files)
+	result, specificity := loadEnvData(files, ctx)
 	for k, _ := range result {
 		keys = append(keys, k)
 	}
 	sort.Strings(keys)
 	data := make(map[string]string, 0)
+	ranks := make(map[string]int, 0)
 	for _, k := range keys {
 		namedData := result[k]
 		for attrib, value := range namedData {
 			old, exists := data[attrib]
-			if exists {
+			if exists && specificity[k] < ranks[attrib] {
+				continue
+			}
+			if exists && specificity[k] == ranks[attrib] {
 				applog.Tag("load-vars").WithAttribute("key", attrib).
 					WithAttribute("new_value", value).WithAttribute("prev_value", old).
 					Error("data for attribute %s already exists", attrib)
 			}
 			data[attrib] = value
+			ranks[attrib] = specificity[k]
 		}
 	}
-	data["REGION"] = environment.Region()
-	data["ENV_NAME"] = environment.EnvName()
-	data["CLUSTER"] = environment.Cluster()
+	data["REGION"] = ctx.Region
+	data["ENV_NAME"] = ctx.EnvName
+	data["ENV_TIER"] = ctx.Tier
+	data["CLUSTER"] = ctx.Cluster
+	data["LOCATION"] = ctx.Location
+	data["CLOUD_PROVIDER"] = ctx.Provider
+	data["ZONES"] = strings.Join(ctx.Zones, ",")
+	data["REGISTRY_MIRROR"] = ctx.RegistryMirror
 	return data
 }
 
-func loadEnvData(files []string) map[string]map[string]string {
+// loadEnvData keeps, for every name, the data of the most specific matching document and the specificity of its selector,
+// a name no document matches has no data
+func loadEnvData(files []string, ctx environment.Context) (map[string]map[string]string, map[string]int) {
 	errors := make([]string, 0)
 	variables := make(map[string]map[string]string, 0)
+	specificity := make(map[string]int, 0)
+	ranking := selector.NewRanking("environment", ctx)
 	for _, file := range files {
 		envData := Environment{}
 		err := functions.UnmarshalFile(file, &envData)
@@ -56,39 +107,23 @@ func loadEnvData(files []string) map[string]map[string]string {
 		if envData.Kind != "Environment" {
 			continue
 		}
-		data := make(map[string]string, 0)
-		if matchBySelector(envData) {
-			for _, kv := range envData.Spec.Data {
-				data[kv.Name] = kv.Value
+		name := envData.Metadata.Name
+		if !ranking.Accept(name, file, envData.Spec.Selector) {
+			if _, exists := variables[name]; !exists {
+				variables[name] = make(map[string]string, 0)
+				specificity[name] = -1
 			}
+			continue
 		}
-		variables[envData.Metadata.Name] = data
+		data := make(map[string]string, 0)
+		for _, kv := range envData.Spec.Data {
+			data[kv.Name] = kv.Value
+		}
+		variables[name] = data
+		specificity[name], _ = ranking.Specificity(name)
 	}
 	if len(errors) > 0 {
 		applog.Tag("load-vars").Error("errors while loading environment data: %s", errors)
 	}
-	return variables
-}
-
-func matchBySelector(envData Environment) bool {
-	selector := envData.Spec.Selector
-	includedByEnv := true
-	includedByLoc := true
-	include := true
-	if len(selector) != 0 {
-		targetEnv, ok := selector["ENV_NAME"]
-		if ok {
-			if targetEnv != environment.EnvName() {
-				includedByEnv = false
-			}
-		}
-		location, ok := selector["LOCATION"]
-		if ok {
-			if location != environment.Region() {
-				includedByLoc = false
-			}
-		}
-		include = includedByEnv && includedByLoc
-	}
-	return include
+	return variables, specificity
 }
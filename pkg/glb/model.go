@@ -1,5 +1,7 @@
 package glb
 
+import "github.com/skhatri/shores/pkg/selector"
+
 type Environment struct {
 	Kind string `json:"kind" yaml:"kind"`
 	Metadata Metadata `json:"metadata" yaml:"metadata"`
@@ -9,7 +11,7 @@ type Metadata struct {
 	Name string `json:"name" yaml:"name"`
 }
 type EnvironmentSpec struct {
-	Selector map[string]string `json:"selector" yaml:"selector"`
+	Selector selector.Selector `json:"selector" yaml:"selector"`
 	Data []KeyValue `json:"data" yaml:"data"`
 }
 
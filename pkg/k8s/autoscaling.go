@@ -0,0 +1,66 @@
+package k8s
+
+type CrossVersionObjectReference struct {
+	ApiVersion string `json:"apiVersion" yaml:"apiVersion"`
+	Kind       string `json:"kind" yaml:"kind"`
+	Name       string `json:"name" yaml:"name"`
+}
+
+type HorizontalPodAutoscaler struct {
+	TypeMeta `json:",inline" yaml:",inline"`
+	Metadata ObjectMeta                  `json:"metadata" yaml:"metadata"`
+	Spec     HorizontalPodAutoscalerSpec `json:"spec" yaml:"spec"`
+}
+
+type HorizontalPodAutoscalerSpec struct {
+	ScaleTargetRef CrossVersionObjectReference `json:"scaleTargetRef" yaml:"scaleTargetRef"`
+	MinReplicas    *int                        `json:"minReplicas,omitempty" yaml:"minReplicas,omitempty"`
+	MaxReplicas    int                         `json:"maxReplicas" yaml:"maxReplicas"`
+	Metrics        []MetricSpec                `json:"metrics,omitempty" yaml:"metrics,omitempty"`
+}
+
+type MetricSpec struct {
+	Type     string                `json:"type" yaml:"type"`
+	Resource *ResourceMetricSource `json:"resource,omitempty" yaml:"resource,omitempty"`
+	Pods     *PodsMetricSource     `json:"pods,omitempty" yaml:"pods,omitempty"`
+}
+
+type ResourceMetricSource struct {
+	Name   string       `json:"name" yaml:"name"`
+	Target MetricTarget `json:"target" yaml:"target"`
+}
+
+type PodsMetricSource struct {
+	Metric MetricIdentifier `json:"metric" yaml:"metric"`
+	Target MetricTarget     `json:"target" yaml:"target"`
+}
+
+type MetricIdentifier struct {
+	Name string `json:"name" yaml:"name"`
+}
+
+type MetricTarget struct {
+	Type               string `json:"type" yaml:"type"`
+	AverageUtilization *int   `json:"averageUtilization,omitempty" yaml:"averageUtilization,omitempty"`
+	AverageValue       string `json:"averageValue,omitempty" yaml:"averageValue,omitempty"`
+}
+
+// ScaledObject is the KEDA custom resource driving event based scaling
+type ScaledObject struct {
+	TypeMeta `json:",inline" yaml:",inline"`
+	Metadata ObjectMeta       `json:"metadata" yaml:"metadata"`
+	Spec     ScaledObjectSpec `json:"spec" yaml:"spec"`
+}
+
+type ScaledObjectSpec struct {
+	ScaleTargetRef  CrossVersionObjectReference `json:"scaleTargetRef" yaml:"scaleTargetRef"`
+	MinReplicaCount *int                        `json:"minReplicaCount,omitempty" yaml:"minReplicaCount,omitempty"`
+	MaxReplicaCount *int                        `json:"maxReplicaCount,omitempty" yaml:"maxReplicaCount,omitempty"`
+	Triggers        []ScaleTrigger              `json:"triggers" yaml:"triggers"`
+}
+
+type ScaleTrigger struct {
+	Type       string            `json:"type" yaml:"type"`
+	MetricType string            `json:"metricType,omitempty" yaml:"metricType,omitempty"`
+	Metadata   map[string]string `json:"metadata" yaml:"metadata"`
+}
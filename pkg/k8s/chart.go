@@ -0,0 +1,28 @@
+package k8s
+
+// Chart is the Chart.yaml of a generated helm chart
+type Chart struct {
+	ApiVersion   string            `json:"apiVersion" yaml:"apiVersion"`
+	Name         string            `json:"name" yaml:"name"`
+	Description  string            `json:"description" yaml:"description"`
+	Type         string            `json:"type,omitempty" yaml:"type,omitempty"`
+	Version      string            `json:"version" yaml:"version"`
+	AppVersion   string            `json:"appVersion,omitempty" yaml:"appVersion,omitempty"`
+	Keywords     []string          `json:"keywords,omitempty" yaml:"keywords,omitempty"`
+	Maintainers  []ChartMaintainer `json:"maintainers,omitempty" yaml:"maintainers,omitempty"`
+	Dependencies []ChartDependency `json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+}
+
+type ChartDependency struct {
+	Name       string `json:"name" yaml:"name"`
+	Version    string `json:"version" yaml:"version"`
+	Repository string `json:"repository" yaml:"repository"`
+	Condition  string `json:"condition,omitempty" yaml:"condition,omitempty"`
+}
+
+type ChartMaintainer struct {
+	Name  string `json:"name" yaml:"name"`
+	Email string `json:"email,omitempty" yaml:"email,omitempty"`
+	Url   string `json:"url,omitempty" yaml:"url,omitempty"`
+}
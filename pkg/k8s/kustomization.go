@@ -0,0 +1,23 @@
+package k8s
+
+// Kustomization is the kustomization.yaml of a generated base or overlay
+type Kustomization struct {
+	TypeMeta  `json:",inline" yaml:",inline"`
+	Namespace string               `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Resources []string             `json:"resources,omitempty" yaml:"resources,omitempty"`
+	Patches   []KustomizationPatch `json:"patches,omitempty" yaml:"patches,omitempty"`
+}
+
+// KustomizationPatch is a strategic merge patch file, or a json6902 patch file when it has a target
+type KustomizationPatch struct {
+	Path   string               `json:"path" yaml:"path"`
+	Target *KustomizationTarget `json:"target,omitempty" yaml:"target,omitempty"`
+}
+
+type KustomizationTarget struct {
+	Group     string `json:"group,omitempty" yaml:"group,omitempty"`
+	Version   string `json:"version,omitempty" yaml:"version,omitempty"`
+	Kind      string `json:"kind" yaml:"kind"`
+	Name      string `json:"name" yaml:"name"`
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+}
@@ -0,0 +1,38 @@
+package k8s
+
+import "strconv"
+
+type TypeMeta struct {
+	ApiVersion string `json:"apiVersion" yaml:"apiVersion"`
+	Kind       string `json:"kind" yaml:"kind"`
+}
+
+type ObjectMeta struct {
+	Name        string            `json:"name,omitempty" yaml:"name,omitempty"`
+	Namespace   string            `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+}
+
+type LabelSelector struct {
+	MatchLabels map[string]string `json:"matchLabels,omitempty" yaml:"matchLabels,omitempty"`
+}
+
+// IntOrString is written as a number when it holds one, otherwise as a string such as 25% or a port name
+type IntOrString string
+
+func (v IntOrString) MarshalYAML() (interface{}, error) {
+	if i, err := strconv.Atoi(string(v)); err == nil {
+		return i, nil
+	}
+	return string(v), nil
+}
+
+// NewIntOrString returns nil for a nil or empty value
+func NewIntOrString(value *string) *IntOrString {
+	if value == nil || *value == "" {
+		return nil
+	}
+	v := IntOrString(*value)
+	return &v
+}
@@ -0,0 +1,13 @@
+package k8s
+
+type PodDisruptionBudget struct {
+	TypeMeta `json:",inline" yaml:",inline"`
+	Metadata ObjectMeta              `json:"metadata" yaml:"metadata"`
+	Spec     PodDisruptionBudgetSpec `json:"spec" yaml:"spec"`
+}
+
+type PodDisruptionBudgetSpec struct {
+	MinAvailable   *IntOrString  `json:"minAvailable,omitempty" yaml:"minAvailable,omitempty"`
+	MaxUnavailable *IntOrString  `json:"maxUnavailable,omitempty" yaml:"maxUnavailable,omitempty"`
+	Selector       LabelSelector `json:"selector" yaml:"selector"`
+}
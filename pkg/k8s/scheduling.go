@@ -0,0 +1,43 @@
+package k8s
+
+type Affinity struct {
+	NodeAffinity *NodeAffinity `json:"nodeAffinity,omitempty" yaml:"nodeAffinity,omitempty"`
+}
+
+type NodeAffinity struct {
+	Required  *NodeSelector             `json:"requiredDuringSchedulingIgnoredDuringExecution,omitempty" yaml:"requiredDuringSchedulingIgnoredDuringExecution,omitempty"`
+	Preferred []PreferredSchedulingTerm `json:"preferredDuringSchedulingIgnoredDuringExecution,omitempty" yaml:"preferredDuringSchedulingIgnoredDuringExecution,omitempty"`
+}
+
+type NodeSelector struct {
+	NodeSelectorTerms []NodeSelectorTerm `json:"nodeSelectorTerms" yaml:"nodeSelectorTerms"`
+}
+
+type NodeSelectorTerm struct {
+	MatchExpressions []NodeSelectorRequirement `json:"matchExpressions" yaml:"matchExpressions"`
+}
+
+type NodeSelectorRequirement struct {
+	Key      string   `json:"key" yaml:"key"`
+	Operator string   `json:"operator" yaml:"operator"`
+	Values   []string `json:"values,omitempty" yaml:"values,omitempty"`
+}
+
+type PreferredSchedulingTerm struct {
+	Weight     int              `json:"weight" yaml:"weight"`
+	Preference NodeSelectorTerm `json:"preference" yaml:"preference"`
+}
+
+type Toleration struct {
+	Key      string `json:"key,omitempty" yaml:"key,omitempty"`
+	Operator string `json:"operator" yaml:"operator"`
+	Value    string `json:"value,omitempty" yaml:"value,omitempty"`
+	Effect   string `json:"effect,omitempty" yaml:"effect,omitempty"`
+}
+
+type TopologySpreadConstraint struct {
+	MaxSkew           int           `json:"maxSkew" yaml:"maxSkew"`
+	TopologyKey       string        `json:"topologyKey" yaml:"topologyKey"`
+	WhenUnsatisfiable string        `json:"whenUnsatisfiable" yaml:"whenUnsatisfiable"`
+	LabelSelector     LabelSelector `json:"labelSelector" yaml:"labelSelector"`
+}
@@ -0,0 +1,26 @@
+package k8s
+
+type ServiceAccount struct {
+	TypeMeta `json:",inline" yaml:",inline"`
+	Metadata ObjectMeta `json:"metadata" yaml:"metadata"`
+}
+
+type Service struct {
+	TypeMeta `json:",inline" yaml:",inline"`
+	Metadata ObjectMeta  `json:"metadata" yaml:"metadata"`
+	Spec     ServiceSpec `json:"spec" yaml:"spec"`
+}
+
+type ServiceSpec struct {
+	Type      string            `json:"type" yaml:"type"`
+	ClusterIP string            `json:"clusterIP,omitempty" yaml:"clusterIP,omitempty"`
+	Ports     []ServicePort     `json:"ports,omitempty" yaml:"ports,omitempty"`
+	Selector  map[string]string `json:"selector,omitempty" yaml:"selector,omitempty"`
+}
+
+type ServicePort struct {
+	Name       string      `json:"name,omitempty" yaml:"name,omitempty"`
+	Port       int         `json:"port" yaml:"port"`
+	TargetPort IntOrString `json:"targetPort,omitempty" yaml:"targetPort,omitempty"`
+	Protocol   string      `json:"protocol,omitempty" yaml:"protocol,omitempty"`
+}
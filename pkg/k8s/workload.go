@@ -0,0 +1,161 @@
+package k8s
+
+type Deployment struct {
+	TypeMeta `json:",inline" yaml:",inline"`
+	Metadata ObjectMeta     `json:"metadata" yaml:"metadata"`
+	Spec     DeploymentSpec `json:"spec" yaml:"spec"`
+}
+
+type DeploymentSpec struct {
+	Replicas             *int                `json:"replicas,omitempty" yaml:"replicas,omitempty"`
+	Strategy             *DeploymentStrategy `json:"strategy,omitempty" yaml:"strategy,omitempty"`
+	MinReadySeconds      *int                `json:"minReadySeconds,omitempty" yaml:"minReadySeconds,omitempty"`
+	RevisionHistoryLimit *int                `json:"revisionHistoryLimit,omitempty" yaml:"revisionHistoryLimit,omitempty"`
+	Selector             LabelSelector       `json:"selector" yaml:"selector"`
+	Template             PodTemplateSpec     `json:"template" yaml:"template"`
+}
+
+type DeploymentStrategy struct {
+	Type          string                   `json:"type" yaml:"type"`
+	RollingUpdate *RollingUpdateDeployment `json:"rollingUpdate,omitempty" yaml:"rollingUpdate,omitempty"`
+}
+
+type RollingUpdateDeployment struct {
+	MaxSurge       *IntOrString `json:"maxSurge,omitempty" yaml:"maxSurge,omitempty"`
+	MaxUnavailable *IntOrString `json:"maxUnavailable,omitempty" yaml:"maxUnavailable,omitempty"`
+}
+
+type Job struct {
+	TypeMeta `json:",inline" yaml:",inline"`
+	Metadata ObjectMeta `json:"metadata" yaml:"metadata"`
+	Spec     JobSpec    `json:"spec" yaml:"spec"`
+}
+
+type JobSpec struct {
+	Completions             *int            `json:"completions,omitempty" yaml:"completions,omitempty"`
+	Parallelism             *int            `json:"parallelism,omitempty" yaml:"parallelism,omitempty"`
+	BackoffLimit            *int            `json:"backoffLimit,omitempty" yaml:"backoffLimit,omitempty"`
+	ActiveDeadlineSeconds   *int            `json:"activeDeadlineSeconds,omitempty" yaml:"activeDeadlineSeconds,omitempty"`
+	TTLSecondsAfterFinished *int            `json:"ttlSecondsAfterFinished,omitempty" yaml:"ttlSecondsAfterFinished,omitempty"`
+	Template                PodTemplateSpec `json:"template" yaml:"template"`
+}
+
+type PodTemplateSpec struct {
+	Metadata ObjectMeta `json:"metadata" yaml:"metadata"`
+	Spec     PodSpec    `json:"spec" yaml:"spec"`
+}
+
+type PodSpec struct {
+	ServiceAccountName            string                     `json:"serviceAccountName,omitempty" yaml:"serviceAccountName,omitempty"`
+	TerminationGracePeriodSeconds *int                       `json:"terminationGracePeriodSeconds,omitempty" yaml:"terminationGracePeriodSeconds,omitempty"`
+	PriorityClassName             string                     `json:"priorityClassName,omitempty" yaml:"priorityClassName,omitempty"`
+	RestartPolicy                 string                     `json:"restartPolicy,omitempty" yaml:"restartPolicy,omitempty"`
+	Containers                    []Container                `json:"containers" yaml:"containers"`
+	SecurityContext               *PodSecurityContext        `json:"securityContext,omitempty" yaml:"securityContext,omitempty"`
+	NodeSelector                  map[string]string          `json:"nodeSelector,omitempty" yaml:"nodeSelector,omitempty"`
+	Affinity                      *Affinity                  `json:"affinity,omitempty" yaml:"affinity,omitempty"`
+	Tolerations                   []Toleration               `json:"tolerations,omitempty" yaml:"tolerations,omitempty"`
+	TopologySpreadConstraints     []TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty" yaml:"topologySpreadConstraints,omitempty"`
+	Volumes                       []Volume                   `json:"volumes,omitempty" yaml:"volumes,omitempty"`
+}
+
+type Container struct {
+	Name            string                `json:"name" yaml:"name"`
+	Image           string                `json:"image" yaml:"image"`
+	ImagePullPolicy string                `json:"imagePullPolicy,omitempty" yaml:"imagePullPolicy,omitempty"`
+	Command         []string              `json:"command,omitempty" yaml:"command,omitempty"`
+	Args            []string              `json:"args,omitempty" yaml:"args,omitempty"`
+	Ports           []ContainerPort       `json:"ports,omitempty" yaml:"ports,omitempty"`
+	Lifecycle       *Lifecycle            `json:"lifecycle,omitempty" yaml:"lifecycle,omitempty"`
+	LivenessProbe   *Probe                `json:"livenessProbe,omitempty" yaml:"livenessProbe,omitempty"`
+	ReadinessProbe  *Probe                `json:"readinessProbe,omitempty" yaml:"readinessProbe,omitempty"`
+	StartupProbe    *Probe                `json:"startupProbe,omitempty" yaml:"startupProbe,omitempty"`
+	Resources       *ResourceRequirements `json:"resources,omitempty" yaml:"resources,omitempty"`
+	Env             []EnvVar              `json:"env,omitempty" yaml:"env,omitempty"`
+	VolumeMounts    []VolumeMount         `json:"volumeMounts,omitempty" yaml:"volumeMounts,omitempty"`
+	SecurityContext *SecurityContext      `json:"securityContext,omitempty" yaml:"securityContext,omitempty"`
+}
+
+type ContainerPort struct {
+	Name          string `json:"name,omitempty" yaml:"name,omitempty"`
+	ContainerPort int    `json:"containerPort" yaml:"containerPort"`
+	Protocol      string `json:"protocol,omitempty" yaml:"protocol,omitempty"`
+}
+
+type EnvVar struct {
+	Name  string `json:"name" yaml:"name"`
+	Value string `json:"value" yaml:"value"`
+}
+
+type ResourceRequirements struct {
+	Requests map[string]string `json:"requests,omitempty" yaml:"requests,omitempty"`
+	Limits   map[string]string `json:"limits,omitempty" yaml:"limits,omitempty"`
+}
+
+type VolumeMount struct {
+	Name      string `json:"name" yaml:"name"`
+	MountPath string `json:"mountPath" yaml:"mountPath"`
+}
+
+type Volume struct {
+	Name     string    `json:"name" yaml:"name"`
+	EmptyDir *EmptyDir `json:"emptyDir,omitempty" yaml:"emptyDir,omitempty"`
+}
+
+type EmptyDir struct{}
+
+type SecurityContext struct {
+	AllowPrivilegeEscalation *bool  `json:"allowPrivilegeEscalation,omitempty" yaml:"allowPrivilegeEscalation,omitempty"`
+	ReadOnlyRootFilesystem   *bool  `json:"readOnlyRootFilesystem,omitempty" yaml:"readOnlyRootFilesystem,omitempty"`
+	RunAsNonRoot             *bool  `json:"runAsNonRoot,omitempty" yaml:"runAsNonRoot,omitempty"`
+	RunAsUser                *int64 `json:"runAsUser,omitempty" yaml:"runAsUser,omitempty"`
+}
+
+type PodSecurityContext struct {
+	RunAsNonRoot *bool  `json:"runAsNonRoot,omitempty" yaml:"runAsNonRoot,omitempty"`
+	RunAsUser    *int64 `json:"runAsUser,omitempty" yaml:"runAsUser,omitempty"`
+}
+
+type Lifecycle struct {
+	PreStop *LifecycleHandler `json:"preStop,omitempty" yaml:"preStop,omitempty"`
+}
+
+type LifecycleHandler struct {
+	Sleep *SleepAction `json:"sleep,omitempty" yaml:"sleep,omitempty"`
+	Exec  *ExecAction  `json:"exec,omitempty" yaml:"exec,omitempty"`
+}
+
+type SleepAction struct {
+	Seconds int `json:"seconds" yaml:"seconds"`
+}
+
+type ExecAction struct {
+	Command []string `json:"command" yaml:"command"`
+}
+
+type Probe struct {
+	HttpGet             *HTTPGetAction   `json:"httpGet,omitempty" yaml:"httpGet,omitempty"`
+	TcpSocket           *TCPSocketAction `json:"tcpSocket,omitempty" yaml:"tcpSocket,omitempty"`
+	Exec                *ExecAction      `json:"exec,omitempty" yaml:"exec,omitempty"`
+	Grpc                *GRPCAction      `json:"grpc,omitempty" yaml:"grpc,omitempty"`
+	InitialDelaySeconds *int             `json:"initialDelaySeconds,omitempty" yaml:"initialDelaySeconds,omitempty"`
+	PeriodSeconds       *int             `json:"periodSeconds,omitempty" yaml:"periodSeconds,omitempty"`
+	TimeoutSeconds      *int             `json:"timeoutSeconds,omitempty" yaml:"timeoutSeconds,omitempty"`
+	FailureThreshold    *int             `json:"failureThreshold,omitempty" yaml:"failureThreshold,omitempty"`
+	SuccessThreshold    *int             `json:"successThreshold,omitempty" yaml:"successThreshold,omitempty"`
+}
+
+type HTTPGetAction struct {
+	Path   string      `json:"path" yaml:"path"`
+	Port   IntOrString `json:"port" yaml:"port"`
+	Scheme string      `json:"scheme,omitempty" yaml:"scheme,omitempty"`
+}
+
+type TCPSocketAction struct {
+	Port IntOrString `json:"port" yaml:"port"`
+}
+
+type GRPCAction struct {
+	Port    IntOrString `json:"port" yaml:"port"`
+	Service *string     `json:"service,omitempty" yaml:"service,omitempty"`
+}
@@ -0,0 +1,33 @@
+package location
+
+import (
+	"github.com/skhatri/shores/pkg/applog"
+	"github.com/skhatri/shores/pkg/functions"
+	"github.com/skhatri/shores/pkg/model"
+)
+
+// LoadLocations maps every location code to where it deploys, the code is the metadata name
+func LoadLocations(files []string) map[string]model.LocationSpec {
+	errors := make([]string, 0)
+	locations := make(map[string]model.LocationSpec, 0)
+	for _, file := range files {
+		locationKind := Location{}
+		err := functions.UnmarshalFile(file, &locationKind)
+		if err != nil {
+			errors = append(errors, err.Error())
+			continue
+		}
+		if locationKind.Kind != "Location" {
+			continue
+		}
+		if _, exists := locations[locationKind.Metadata.Name]; exists {
+			applog.Tag("load-locations").WithAttribute("location", locationKind.Metadata.Name).
+				Error("location %s is defined more than once, using %s", locationKind.Metadata.Name, file)
+		}
+		locations[locationKind.Metadata.Name] = locationKind.Spec
+	}
+	if len(errors) > 0 {
+		applog.Tag("load-locations").Error("errors while loading locations: %s", errors)
+	}
+	return locations
+}
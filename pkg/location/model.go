@@ -0,0 +1,13 @@
+package location
+
+import "github.com/skhatri/shores/pkg/model"
+
+type Location struct {
+	Kind     string             `json:"kind" yaml:"kind"`
+	Metadata Metadata           `json:"metadata" yaml:"metadata"`
+	Spec     model.LocationSpec `json:"spec" yaml:"spec"`
+}
+
+type Metadata struct {
+	Name string `json:"name" yaml:"name"`
+}
@@ -1,23 +1,43 @@
 package mixin
 
 import (
+	"github.com/skhatri/shores/pkg/applog"
+	"github.com/skhatri/shores/pkg/environment"
 	"github.com/skhatri/shores/pkg/functions"
 	"github.com/skhatri/shores/pkg/model"
+	"github.com/skhatri/shores/pkg/selector"
 )
 
-func LoadMixins(files []string) map[string]model.MixinTemplate {
+// LoadMixins keeps, for every mixin name, the most specific variant whose selector matches the context
+func LoadMixins(files []string, ctx environment.Context) map[string]model.MixinTemplate {
 	errors := make([]string, 0)
 	mixins := make(map[string]model.MixinTemplate, 0)
+	ranking := selector.NewRanking("mixin", ctx)
 	for _, file := range files {
 		mixinKind := Mixin{}
 		err := functions.UnmarshalFile(file, &mixinKind)
 		if err != nil {
 			errors = append(errors, err.Error())
+			continue
 		}
-		if mixinKind.Kind != "Mixin" {
+		if mixinKind.Kind != "Mixin" || !ranking.Accept(mixinKind.Metadata.Name, file, mixinKind.Spec.Selector) {
 			continue
 		}
-		mixins[mixinKind.Metadata.Name] = mixinKind.Spec.Template
+		template := mixinKind.Spec.Template
+		template.Name = mixinKind.Metadata.Name
+		template.Salience = mixinKind.Spec.Salience
+		for _, field := range mixinKind.Spec.Locked {
+			if !model.IsMixinField(field) {
+				applog.Tag("load-mixins").WithAttribute("mixin", template.Name).
+					WithAttribute("field", field).Error("locked field %s is not a mixin field", field)
+				continue
+			}
+			template.Locked = append(template.Locked, field)
+		}
+		mixins[mixinKind.Metadata.Name] = template
+	}
+	if len(errors) > 0 {
+		applog.Tag("load-mixins").Error("errors while loading mixins: %s", errors)
 	}
 	return mixins
 }
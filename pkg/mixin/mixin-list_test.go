@@ -0,0 +1,45 @@
+package mixin
+
+import (
+	"github.com/skhatri/shores/pkg/environment"
+	"github.com/skhatri/shores/pkg/functions"
+	"reflect"
+	"testing"
+)
+
+func TestLoadMixinsSetsNameSalienceAndLockedFields(t *testing.T) {
+	mixins := LoadMixins(functions.ListFiles("testdata/locked", ".yaml"), environment.Context{EnvName: "dev"})
+	mixin, ok := mixins["base"]
+	if !ok {
+		t.Fatal("mixin base was not loaded")
+	}
+	if mixin.Name != "base" || mixin.Salience != 5 {
+		t.Errorf("name %s and salience %d, want base and 5", mixin.Name, mixin.Salience)
+	}
+	if !reflect.DeepEqual(mixin.Locked, []string{"cpu"}) {
+		t.Errorf("locked = %v, want only the mixin field cpu", mixin.Locked)
+	}
+}
+
+func TestLoadMixinsPicksTheVariantOfTheContext(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  environment.Context
+		cpu  string
+	}{
+		{"default variant", environment.Context{EnvName: "dev"}, "c0"},
+		{"selected variant, skipping the broken file and other kinds", environment.Context{EnvName: "prod", Cluster: "gke-1"}, "c1"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mixins := LoadMixins(functions.ListFiles("testdata/variants", ".yaml"), test.ctx)
+			mixin, ok := mixins["base"]
+			if !ok {
+				t.Fatal("mixin base was not loaded")
+			}
+			if mixin.Cpu == nil || *mixin.Cpu != test.cpu {
+				t.Errorf("cpu = %v, want %s", mixin.Cpu, test.cpu)
+			}
+		})
+	}
+}
@@ -1,11 +1,14 @@
 package mixin
 
-import "github.com/skhatri/shores/pkg/model"
+import (
+	"github.com/skhatri/shores/pkg/model"
+	"github.com/skhatri/shores/pkg/selector"
+)
 
 type Mixin struct {
-	Kind string `json:"kind" yaml:"kind"`
-	Metadata Metadata `json:"metadata" yaml:"metadata"`
-	Spec MixinSpec `json:"spec" yaml:"spec"`
+	Kind     string    `json:"kind" yaml:"kind"`
+	Metadata Metadata  `json:"metadata" yaml:"metadata"`
+	Spec     MixinSpec `json:"spec" yaml:"spec"`
 }
 
 type Metadata struct {
@@ -13,6 +16,8 @@ type Metadata struct {
 }
 
 type MixinSpec struct {
-	Selector map[string]string `json:"selector" yaml:"selector"`
+	Selector selector.Selector   `json:"selector" yaml:"selector"`
+	Salience int                 `json:"salience" yaml:"salience"`
+	Locked   []string            `json:"locked" yaml:"locked"`
 	Template model.MixinTemplate `json:"template" yaml:"template"`
 }
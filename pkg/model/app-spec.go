@@ -1,6 +1,8 @@
 package model
 
+// AppSpec is an app under spec/user/apps, kind is deployment unless given as job
 type AppSpec struct {
+	Kind            string               `json:"kind" yaml:"kind"`
 	Name            string               `json:"name" yaml:"name"`
 	Image           string               `json:"image" yaml:"image"`
 	Env             []Env                `json:"env" yaml:"env"`
@@ -14,7 +16,16 @@ type AppSpec struct {
 	Mixins          []string             `json:"mixins" yaml:"mixins"`
 	Ingress         *IngressSpec         `json:"ingress" yaml:"ingress"`
 	Mounts          []string             `json:"mounts" yaml:"mounts"`
-	Args            *ArgsSpec             `json:"args" yaml:"args"`
+	Args            *ArgsSpec            `json:"args" yaml:"args"`
+	Stack           *string              `json:"stack" yaml:"stack"`
+	Cpu             *string              `json:"cpu" yaml:"cpu"`
+	Memory          *string              `json:"memory" yaml:"memory"`
+	Probes          *ProbesSpec          `json:"probes" yaml:"probes"`
+	Team            *string              `json:"team" yaml:"team"`
+	Keywords        []string             `json:"keywords" yaml:"keywords"`
+
+	ResourceLimitStrategy *string `json:"resource-limit-strategy" yaml:"resource-limit-strategy"`
+	ResourceCombine       *string `json:"resource-combine" yaml:"resource-combine"`
 }
 
 type Env struct {
@@ -41,8 +52,15 @@ type ServiceSpec struct {
 }
 
 type WorkloadSpec struct {
-	Target  string  `json:"target" yaml:"target"`
-	Scaling *string `json:"scaling" yaml:"scaling"`
+	Target      string           `json:"target" yaml:"target"`
+	Scaling     *string          `json:"scaling" yaml:"scaling"`
+	Replicas    *int             `json:"replicas" yaml:"replicas"`
+	Autoscaling *AutoscalingSpec `json:"autoscaling" yaml:"autoscaling"`
+	// DefaultReplicas comes from a stack and is used only when no scaling group is chosen
+	DefaultReplicas *int `json:"-" yaml:"-"`
+
+	DisruptionBudget *DisruptionBudgetSpec `json:"disruptionBudget" yaml:"disruptionBudget"`
+	Rollout          *RolloutSpec          `json:"rollout" yaml:"rollout"`
 }
 
 type SecurityContextSpec struct {
@@ -53,6 +71,7 @@ type SecurityContextSpec struct {
 }
 
 type IngressSpec struct {
-	Name  string `json:"name" yaml:"name"`
-	Group string `json:"group" yaml:"group"`
+	Name  string   `json:"name" yaml:"name"`
+	Group string   `json:"group" yaml:"group"`
+	Hosts []string `json:"hosts,omitempty" yaml:"hosts,omitempty"`
 }
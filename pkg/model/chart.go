@@ -0,0 +1,21 @@
+package model
+
+// ChartInfo is the Chart.yaml metadata of the app, the version is assigned by the chart versioning scheme
+type ChartInfo struct {
+	Version     string       `json:"version"`
+	AppVersion  string       `json:"appVersion"`
+	Type        string       `json:"type"`
+	Maintainers []Maintainer `json:"maintainers,omitempty"`
+	Keywords    []string     `json:"keywords,omitempty"`
+	ContentHash string       `json:"contentHash,omitempty"`
+}
+
+type Maintainer struct {
+	Name  string `json:"name" yaml:"name"`
+	Email string `json:"email,omitempty" yaml:"email,omitempty"`
+	Url   string `json:"url,omitempty" yaml:"url,omitempty"`
+}
+
+type TeamSpec struct {
+	Maintainers []Maintainer `json:"maintainers" yaml:"maintainers"`
+}
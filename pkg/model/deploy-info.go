@@ -9,4 +9,7 @@ type DeploymentItem struct {
 	Name string
 	Kind string
 	Path string
+	Qos  string
+
+	ChartVersion string
 }
@@ -1,28 +1,30 @@
 package model
 
-import (
-	"regexp"
-	"strings"
-)
-
 type Deployable struct {
-	Kind               string               `json:"kind"`
-	Namespace          string               `json:"namespace"`
-	Artifact           ArtifactInfo         `json:"artifact"`
-	Checks             *Healthcheck         `json:"checks"`
-	Target             TargetInfo           `json:"target"`
-	Env                map[string]string    `json:"env"`
-	InitContainer      []InitContainerInfo  `json:"initContainer"`
-	Sidecar            []SidecarInfo        `json:"sidecar"`
-	Service            []ServiceInfo        `json:"service"`
-	Metadata           Metadata             `json:"metadata"`
-	ServiceAccountName *string              `json:"serviceAccountName"`
-	ServiceEnabled     bool                 `json:"serviceEnabled"`
-	Resources          *Resources           `json:"resources"`
-	SecurityContext    *SecurityContextSpec `json:"securityContext"`
-	Ingress            *IngressSpec         `json:"ingress"`
-	Mounts             []MountSpec          `json:"mounts"`
-	Args               *ArgsSpec            `json:"args"`
+	Kind               string                `json:"kind"`
+	Namespace          string                `json:"namespace"`
+	Artifact           ArtifactInfo          `json:"artifact"`
+	Probes             *ProbesSpec           `json:"probes"`
+	Target             TargetInfo            `json:"target"`
+	Env                map[string]string     `json:"env"`
+	InitContainer      []InitContainerInfo   `json:"initContainer"`
+	Sidecar            []SidecarInfo         `json:"sidecar"`
+	Service            []ServiceInfo         `json:"service"`
+	Metadata           Metadata              `json:"metadata"`
+	ServiceAccountName *string               `json:"serviceAccountName"`
+	ServiceEnabled     bool                  `json:"serviceEnabled"`
+	Resources          *Resources            `json:"resources"`
+	SecurityContext    *SecurityContextSpec  `json:"securityContext"`
+	Ingress            *IngressSpec          `json:"ingress"`
+	Mounts             []MountSpec           `json:"mounts"`
+	Args               *ArgsSpec             `json:"args"`
+	QosClass           string                `json:"qosClass"`
+	ResourceVariants   []ResourceVariant     `json:"resourceVariants,omitempty"`
+	Scaling            *ScalingGroupSpec     `json:"scaling,omitempty"`
+	Autoscaling        *AutoscalingSpec      `json:"autoscaling,omitempty"`
+	DisruptionBudget   *DisruptionBudgetSpec `json:"disruptionBudget,omitempty"`
+	Rollout            *RolloutSpec          `json:"rollout,omitempty"`
+	Chart              ChartInfo             `json:"chart"`
 }
 
 func (d *Deployable) Ports() []PortType {
@@ -52,22 +54,6 @@ type ArtifactInfo struct {
 	Image string `json:"image,omitempty"`
 }
 
-var semVerVersion = regexp.MustCompile("^v?([0-9]+\\.[0-9]+\\.[0-9]+)$")
-
-func (ai *ArtifactInfo) Version() string {
-	if ai.Image == "" {
-		return ""
-	}
-	parts := strings.Split(ai.Image, ":")
-	if len(parts) < 2 {
-		return ""
-	}
-	if semVerVersion.MatchString(parts[1]) {
-		return semVerVersion.FindStringSubmatch(parts[1])[1]
-	}
-	return "1.0.0"
-}
-
 type ReleaseInfo struct {
 	Name    string `json:"name,omitempty"`
 	Version string `json:"version,omitempty"`
@@ -90,19 +76,26 @@ type PortType struct {
 	Protocol   string `json:"protocol,omitempty"`
 }
 
-type Healthcheck struct {
-	Port string `json:"port"`
-	Path string `json:"path"`
-}
-
 type TargetInfo struct {
 	Replica      int               `json:"replica"`
 	NodeSelector map[string]string `json:"nodeSelector"`
+	ScalingGroup string            `json:"scalingGroup"`
+
+	Tolerations    []Toleration               `json:"tolerations,omitempty"`
+	Affinity       *NodeAffinity              `json:"affinity,omitempty"`
+	TopologySpread []TopologySpreadConstraint `json:"topologySpread,omitempty"`
 }
 
 type Resources struct {
 	Requests *ResourceValue `json:"requests"`
 	Limits   *ResourceValue `json:"limits"`
+	Strategy *string        `json:"strategy,omitempty" yaml:"-"`
+	Variant  string         `json:"-" yaml:"-"`
+}
+
+type ResourceVariant struct {
+	Name    string `json:"name"`
+	Variant string `json:"variant"`
 }
 
 type ResourceValue struct {
@@ -117,6 +110,6 @@ type MountSpec struct {
 }
 
 type ArgsSpec struct {
-	Entrypoint []*string  `json:"entrypoint"`
+	Entrypoint []*string `json:"entrypoint"`
 	Command    []*string `json:"command"`
 }
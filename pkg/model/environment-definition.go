@@ -0,0 +1,16 @@
+package model
+
+const (
+	TierDev  = "dev"
+	TierTest = "test"
+	TierProd = "prod"
+)
+
+// EnvironmentDefinitionSpec declares an environment, values missing for it are looked up along the fallback chain
+type EnvironmentDefinitionSpec struct {
+	Aliases  []string          `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+	Tier     string            `json:"tier" yaml:"tier"`
+	Fallback *string           `json:"fallback,omitempty" yaml:"fallback,omitempty"`
+	Policies []string          `json:"policies,omitempty" yaml:"policies,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+}
@@ -0,0 +1,11 @@
+package model
+
+// LocationSpec describes where a location code deploys to
+type LocationSpec struct {
+	Provider       string            `json:"provider" yaml:"provider"`
+	Region         string            `json:"region" yaml:"region"`
+	Zones          []string          `json:"zones,omitempty" yaml:"zones,omitempty"`
+	RegistryMirror string            `json:"registryMirror,omitempty" yaml:"registry_mirror,omitempty"`
+	DefaultCluster string            `json:"defaultCluster,omitempty" yaml:"default_cluster,omitempty"`
+	Labels         map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+}
@@ -1,24 +1,42 @@
 package model
 
+import "sort"
+
+var mixinFields = []string{"secrets", "sidecar", "service", "workload", "resources", "securityContext", "args", "env", "cpu", "memory", "probes", "resource-limit-strategy", "resource-combine"}
+
+func IsMixinField(field string) bool {
+	for _, name := range mixinFields {
+		if name == field {
+			return true
+		}
+	}
+	return false
+}
+
 type MixinTemplate struct {
+	Name            string               `json:"-" yaml:"-"`
+	Salience        int                  `json:"-" yaml:"-"`
+	Locked          []string             `json:"-" yaml:"-"`
 	Secrets         *SecretSpec          `json:"secrets,omitempty" yaml:"secrets,omitempty"`
 	Sidecar         []*SidecarSpec       `json:"sidecar,omitempty" yaml:"sidecar,omitempty"`
 	Service         *ServiceSpec         `json:"service,omitempty" yaml:"service,omitempty"`
 	Workload        *WorkloadSpec        `json:"workload,omitempty" yaml:"workload,omitempty"`
 	Resources       []*string            `json:"resources,omitempty" yaml:"resources,omitempty"`
 	SecurityContext *SecurityContextSpec `json:"securityContext,omitempty" yaml:"securityContext,omitempty"`
-	Args            *ArgsSpec             `json:"args,omitempty" yaml:"args,omitempty"`
+	Args            *ArgsSpec            `json:"args,omitempty" yaml:"args,omitempty"`
+	Env             []Env                `json:"env,omitempty" yaml:"env,omitempty"`
+	Cpu             *string              `json:"cpu,omitempty" yaml:"cpu,omitempty"`
+	Memory          *string              `json:"memory,omitempty" yaml:"memory,omitempty"`
+	Probes          *ProbesSpec          `json:"probes,omitempty" yaml:"probes,omitempty"`
+
+	ResourceLimitStrategy *string `json:"resource-limit-strategy,omitempty" yaml:"resource-limit-strategy,omitempty"`
+	ResourceCombine       *string `json:"resource-combine,omitempty" yaml:"resource-combine,omitempty"`
 }
 
 func (mx *MixinTemplate) Merge(other *MixinTemplate) *MixinTemplate {
 	newTemplate := MixinTemplate{}
 
-	myWorkload := mx.Workload
-	theirWorkload := other.Workload
-	newTemplate.Workload = myWorkload
-	if theirWorkload != nil {
-		newTemplate.Workload = theirWorkload
-	}
+	newTemplate.Workload = MergeWorkload(mx.Workload, other.Workload)
 
 	mergedResources := make([]*string, 0)
 	myResources := mx.Resources
@@ -63,6 +81,36 @@ func (mx *MixinTemplate) Merge(other *MixinTemplate) *MixinTemplate {
 		newTemplate.Args = theirArgs
 	}
 
+	myCpu := mx.Cpu
+	theirCpu := other.Cpu
+	newTemplate.Cpu = myCpu
+	if theirCpu != nil {
+		newTemplate.Cpu = theirCpu
+	}
+
+	myMemory := mx.Memory
+	theirMemory := other.Memory
+	newTemplate.Memory = myMemory
+	if theirMemory != nil {
+		newTemplate.Memory = theirMemory
+	}
+
+	myStrategy := mx.ResourceLimitStrategy
+	theirStrategy := other.ResourceLimitStrategy
+	newTemplate.ResourceLimitStrategy = myStrategy
+	if theirStrategy != nil {
+		newTemplate.ResourceLimitStrategy = theirStrategy
+	}
+
+	myCombine := mx.ResourceCombine
+	theirCombine := other.ResourceCombine
+	newTemplate.ResourceCombine = myCombine
+	if theirCombine != nil {
+		newTemplate.ResourceCombine = theirCombine
+	}
+
+	newTemplate.Probes = MergeProbes(mx.Probes, other.Probes)
+	newTemplate.Env = MergeEnv(mx.Env, other.Env)
 
 	sidecarMapping := make(map[string]*SidecarSpec, 0)
 	mySidecars := mx.Sidecar
@@ -86,6 +134,62 @@ func (mx *MixinTemplate) Merge(other *MixinTemplate) *MixinTemplate {
 	return &newTemplate
 }
 
+// MergeWorkload overlays the attributes set in their workload on top of mine, the result is always a copy
+// so callers filling in defaults never write into a shared mixin or stack workload
+func MergeWorkload(mine *WorkloadSpec, theirs *WorkloadSpec) *WorkloadSpec {
+	if mine == nil && theirs == nil {
+		return nil
+	}
+	if mine == nil {
+		workload := *theirs
+		return &workload
+	}
+	workload := *mine
+	if theirs == nil {
+		return &workload
+	}
+	if theirs.Target != "" {
+		workload.Target = theirs.Target
+	}
+	if theirs.Scaling != nil {
+		workload.Scaling = theirs.Scaling
+	}
+	if theirs.Replicas != nil {
+		workload.Replicas = theirs.Replicas
+	}
+	if theirs.DefaultReplicas != nil {
+		workload.DefaultReplicas = theirs.DefaultReplicas
+	}
+	if theirs.Autoscaling != nil {
+		workload.Autoscaling = theirs.Autoscaling
+	}
+	if theirs.DisruptionBudget != nil {
+		workload.DisruptionBudget = theirs.DisruptionBudget
+	}
+	workload.Rollout = MergeRollout(workload.Rollout, theirs.Rollout)
+	return &workload
+}
+
+// MergeEnv keeps the named values of mine unless theirs redefines them, env-set references are accumulated
+func MergeEnv(mine []Env, theirs []Env) []Env {
+	merged := make([]Env, 0)
+	redefined := make(map[string]struct{}, 0)
+	for _, env := range theirs {
+		if env.Name != nil {
+			redefined[*env.Name] = struct{}{}
+		}
+	}
+	for _, env := range mine {
+		if env.Name != nil {
+			if _, ok := redefined[*env.Name]; ok {
+				continue
+			}
+		}
+		merged = append(merged, env)
+	}
+	return append(merged, theirs...)
+}
+
 func (mx *MixinTemplate) ReduceMerge(templates []*MixinTemplate) *MixinTemplate {
 	var candidate *MixinTemplate
 	for _, template := range templates {
@@ -101,9 +205,10 @@ func (mx *MixinTemplate) ReduceMerge(templates []*MixinTemplate) *MixinTemplate
 	return candidate.Merge(mx)
 }
 
+// ReduceTemplates merges templates in ascending salience so that higher salience mixins win, list order breaks ties
 func ReduceTemplates(templates []*MixinTemplate) *MixinTemplate {
 	var candidate *MixinTemplate
-	for _, template := range templates {
+	for _, template := range SortBySalience(templates) {
 		if candidate == nil {
 			candidate = template
 		} else {
@@ -112,3 +217,12 @@ func ReduceTemplates(templates []*MixinTemplate) *MixinTemplate {
 	}
 	return candidate
 }
+
+func SortBySalience(templates []*MixinTemplate) []*MixinTemplate {
+	sorted := make([]*MixinTemplate, len(templates))
+	copy(sorted, templates)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Salience < sorted[j].Salience
+	})
+	return sorted
+}
@@ -0,0 +1,37 @@
+package model
+
+type NodePoolSpec struct {
+	NodeSelector   map[string]string          `json:"nodeSelector,omitempty" yaml:"nodeSelector,omitempty"`
+	Tolerations    []Toleration               `json:"tolerations,omitempty" yaml:"tolerations,omitempty"`
+	Affinity       *NodeAffinity              `json:"affinity,omitempty" yaml:"affinity,omitempty"`
+	TopologySpread []TopologySpreadConstraint `json:"topologySpread,omitempty" yaml:"topologySpread,omitempty"`
+}
+
+type Toleration struct {
+	Key      string `json:"key,omitempty" yaml:"key,omitempty"`
+	Operator string `json:"operator,omitempty" yaml:"operator,omitempty"`
+	Value    string `json:"value,omitempty" yaml:"value,omitempty"`
+	Effect   string `json:"effect,omitempty" yaml:"effect,omitempty"`
+}
+
+type NodeAffinity struct {
+	Required  []NodeSelectorRequirement `json:"required,omitempty" yaml:"required,omitempty"`
+	Preferred []WeightedRequirement     `json:"preferred,omitempty" yaml:"preferred,omitempty"`
+}
+
+type NodeSelectorRequirement struct {
+	Key      string   `json:"key" yaml:"key"`
+	Operator string   `json:"operator" yaml:"operator"`
+	Values   []string `json:"values,omitempty" yaml:"values,omitempty"`
+}
+
+type WeightedRequirement struct {
+	Weight                  int `json:"weight" yaml:"weight"`
+	NodeSelectorRequirement `json:",inline" yaml:",inline"`
+}
+
+type TopologySpreadConstraint struct {
+	MaxSkew           int    `json:"maxSkew" yaml:"maxSkew"`
+	TopologyKey       string `json:"topologyKey" yaml:"topologyKey"`
+	WhenUnsatisfiable string `json:"whenUnsatisfiable" yaml:"whenUnsatisfiable"`
+}
@@ -0,0 +1,87 @@
+package model
+
+type ProbesSpec struct {
+	Liveness  *ProbeSpec `json:"liveness,omitempty" yaml:"liveness,omitempty"`
+	Readiness *ProbeSpec `json:"readiness,omitempty" yaml:"readiness,omitempty"`
+	Startup   *ProbeSpec `json:"startup,omitempty" yaml:"startup,omitempty"`
+}
+
+type ProbeSpec struct {
+	HttpGet             *HttpGetAction   `json:"httpGet,omitempty" yaml:"httpGet,omitempty"`
+	TcpSocket           *TcpSocketAction `json:"tcpSocket,omitempty" yaml:"tcpSocket,omitempty"`
+	Exec                *ExecAction      `json:"exec,omitempty" yaml:"exec,omitempty"`
+	Grpc                *GrpcAction      `json:"grpc,omitempty" yaml:"grpc,omitempty"`
+	InitialDelaySeconds *int             `json:"initialDelaySeconds,omitempty" yaml:"initialDelaySeconds,omitempty"`
+	PeriodSeconds       *int             `json:"periodSeconds,omitempty" yaml:"periodSeconds,omitempty"`
+	TimeoutSeconds      *int             `json:"timeoutSeconds,omitempty" yaml:"timeoutSeconds,omitempty"`
+	FailureThreshold    *int             `json:"failureThreshold,omitempty" yaml:"failureThreshold,omitempty"`
+	SuccessThreshold    *int             `json:"successThreshold,omitempty" yaml:"successThreshold,omitempty"`
+}
+
+type HttpGetAction struct {
+	Path   string `json:"path" yaml:"path"`
+	Port   string `json:"port,omitempty" yaml:"port,omitempty"`
+	Scheme string `json:"scheme,omitempty" yaml:"scheme,omitempty"`
+}
+
+type TcpSocketAction struct {
+	Port string `json:"port,omitempty" yaml:"port,omitempty"`
+}
+
+type ExecAction struct {
+	Command []string `json:"command" yaml:"command"`
+}
+
+type GrpcAction struct {
+	Port    string  `json:"port,omitempty" yaml:"port,omitempty"`
+	Service *string `json:"service,omitempty" yaml:"service,omitempty"`
+}
+
+func (p *ProbeSpec) HasHandler() bool {
+	return p.HttpGet != nil || p.TcpSocket != nil || p.Exec != nil || p.Grpc != nil
+}
+
+// MergeProbes overlays each probe of theirs on top of mine
+func MergeProbes(mine *ProbesSpec, theirs *ProbesSpec) *ProbesSpec {
+	if mine == nil {
+		return theirs
+	}
+	if theirs == nil {
+		return mine
+	}
+	return &ProbesSpec{
+		Liveness:  MergeProbe(mine.Liveness, theirs.Liveness),
+		Readiness: MergeProbe(mine.Readiness, theirs.Readiness),
+		Startup:   MergeProbe(mine.Startup, theirs.Startup),
+	}
+}
+
+// MergeProbe keeps my handler unless theirs declares one, timings set in theirs override mine
+func MergeProbe(mine *ProbeSpec, theirs *ProbeSpec) *ProbeSpec {
+	if mine == nil {
+		return theirs
+	}
+	if theirs == nil {
+		return mine
+	}
+	probe := *mine
+	if theirs.HasHandler() {
+		probe.HttpGet = theirs.HttpGet
+		probe.TcpSocket = theirs.TcpSocket
+		probe.Exec = theirs.Exec
+		probe.Grpc = theirs.Grpc
+	}
+	probe.InitialDelaySeconds = overrideInt(probe.InitialDelaySeconds, theirs.InitialDelaySeconds)
+	probe.PeriodSeconds = overrideInt(probe.PeriodSeconds, theirs.PeriodSeconds)
+	probe.TimeoutSeconds = overrideInt(probe.TimeoutSeconds, theirs.TimeoutSeconds)
+	probe.FailureThreshold = overrideInt(probe.FailureThreshold, theirs.FailureThreshold)
+	probe.SuccessThreshold = overrideInt(probe.SuccessThreshold, theirs.SuccessThreshold)
+	return &probe
+}
+
+func overrideInt(mine *int, theirs *int) *int {
+	if theirs != nil {
+		return theirs
+	}
+	return mine
+}
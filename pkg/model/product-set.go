@@ -3,13 +3,34 @@ package model
 import (
 	"fmt"
 	"github.com/skhatri/shores/pkg/functions"
+	"path/filepath"
 	"strings"
 )
 
 type ProductSet struct {
+	Name               string         `json:"name,omitempty" yaml:"name,omitempty"`
 	Namespace          *string        `json:"namespace,omitempty" yaml:"namespace,omitempty"`
 	ContainerNamespace *string        `json:"containerNamespace,omitempty" yaml:"container_namespace,omitempty"`
 	Apps               []*ReleaseSpec `json:"apps,omitempty" yaml:"apps,omitempty"`
+	ChartVersioning    *string        `json:"chartVersioning,omitempty" yaml:"chart_versioning,omitempty"`
+	Umbrella           *UmbrellaSpec  `json:"umbrella,omitempty" yaml:"umbrella,omitempty"`
+	Overlays           []*ContextSpec `json:"overlays,omitempty" yaml:"overlays,omitempty"`
+	Matrix             []*ContextSpec `json:"matrix,omitempty" yaml:"matrix,omitempty"`
+}
+
+// UmbrellaSpec asks for a chart depending on every app chart of the release set
+type UmbrellaSpec struct {
+	Name    *string `json:"name,omitempty" yaml:"name,omitempty"`
+	Version *string `json:"version,omitempty" yaml:"version,omitempty"`
+}
+
+// ContextSpec is an environment the release is rendered for, a kustomize overlay or an entry of the matrix
+type ContextSpec struct {
+	Name     string            `json:"name,omitempty" yaml:"name,omitempty"`
+	EnvName  string            `json:"env" yaml:"env"`
+	Location string            `json:"location,omitempty" yaml:"location,omitempty"`
+	Cluster  string            `json:"cluster,omitempty" yaml:"cluster,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
 }
 
 type ReleaseSpec struct {
@@ -25,6 +46,9 @@ func NewProductSetFromFile(file string, namespaceOverride string) (*ProductSet,
 	if err != nil {
 		return nil, err
 	}
+	if productSet.Name == "" {
+		productSet.Name = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	}
 	namespace := "default"
 	if namespaceOverride != "" {
 		productSet.Namespace = &namespaceOverride
@@ -36,6 +60,11 @@ func NewProductSetFromFile(file string, namespaceOverride string) (*ProductSet,
 	if productSet.ContainerNamespace != nil {
 		prefix = fmt.Sprintf("%s/", *productSet.ContainerNamespace)
 	}
+	for _, contextSpec := range append(productSet.Overlays, productSet.Matrix...) {
+		if contextSpec.Name == "" {
+			contextSpec.Name = strings.Join(nonEmpty(contextSpec.EnvName, contextSpec.Location, contextSpec.Cluster), "-")
+		}
+	}
 	for _, appRef := range productSet.Apps {
 		appRef.Namespace = *productSet.Namespace
 		if appRef.Image == nil {
@@ -60,6 +89,24 @@ func NewProductSetFromFile(file string, namespaceOverride string) (*ProductSet,
 	return &productSet, nil
 }
 
+func nonEmpty(values ...string) []string {
+	parts := make([]string, 0)
+	for _, value := range values {
+		if value != "" {
+			parts = append(parts, value)
+		}
+	}
+	return parts
+}
+
+const (
+	ModeManifests = "manifests"
+	// ModeKustomize writes a kustomize base per app and an overlay per environment
+	ModeKustomize = "kustomize"
+	// StdoutOutput as the task output streams the manifests to stdout
+	StdoutOutput = "-"
+)
+
 type Task struct {
 	User      string `json:"user" yaml:"user"`
 	ReleaseId string `json:"releaseId" yaml:"releaseId"`
@@ -68,4 +115,13 @@ type Task struct {
 	Created   string `json:"created" yaml:"created"`
 	ChangeRef string `json:"changeRef" yaml:"changeRef"`
 	Output    string `json:"output" yaml:"output"`
+	Renderer  string `json:"renderer,omitempty" yaml:"renderer,omitempty"`
+	Mode      string `json:"mode,omitempty" yaml:"mode,omitempty"`
+	ChartRepo string `json:"chartRepo,omitempty" yaml:"chartRepo,omitempty"`
+	Matrix    bool   `json:"matrix,omitempty" yaml:"matrix,omitempty"`
+}
+
+// HelmChart reports whether the output is a helm chart, plain manifests and kustomize carry no helm labels
+func (t *Task) HelmChart() bool {
+	return t.Mode != ModeManifests && t.Mode != ModeKustomize
 }
@@ -0,0 +1,49 @@
+package model
+
+type RolloutSpec struct {
+	Strategy                      *string      `json:"strategy,omitempty" yaml:"strategy,omitempty"`
+	MaxSurge                      *string      `json:"maxSurge,omitempty" yaml:"maxSurge,omitempty"`
+	MaxUnavailable                *string      `json:"maxUnavailable,omitempty" yaml:"maxUnavailable,omitempty"`
+	MinReadySeconds               *int         `json:"minReadySeconds,omitempty" yaml:"minReadySeconds,omitempty"`
+	RevisionHistoryLimit          *int         `json:"revisionHistoryLimit,omitempty" yaml:"revisionHistoryLimit,omitempty"`
+	TerminationGracePeriodSeconds *int         `json:"terminationGracePeriodSeconds,omitempty" yaml:"terminationGracePeriodSeconds,omitempty"`
+	PriorityClassName             *string      `json:"priorityClassName,omitempty" yaml:"priorityClassName,omitempty"`
+	PreStop                       *PreStopSpec `json:"preStop,omitempty" yaml:"preStop,omitempty"`
+}
+
+// PreStopSpec delays container shutdown, sleepSeconds renders the native sleep action which needs kubernetes 1.30
+// (1.29 with the PodLifecycleSleepAction feature gate), older clusters need exec: ["sleep", "10"] instead
+type PreStopSpec struct {
+	SleepSeconds *int     `json:"sleepSeconds,omitempty" yaml:"sleepSeconds,omitempty"`
+	Exec         []string `json:"exec,omitempty" yaml:"exec,omitempty"`
+}
+
+// MergeRollout overlays the settings of theirs on top of mine
+func MergeRollout(mine *RolloutSpec, theirs *RolloutSpec) *RolloutSpec {
+	if mine == nil {
+		return theirs
+	}
+	if theirs == nil {
+		return mine
+	}
+	rollout := *mine
+	if theirs.Strategy != nil {
+		rollout.Strategy = theirs.Strategy
+	}
+	if theirs.MaxSurge != nil {
+		rollout.MaxSurge = theirs.MaxSurge
+	}
+	if theirs.MaxUnavailable != nil {
+		rollout.MaxUnavailable = theirs.MaxUnavailable
+	}
+	if theirs.PriorityClassName != nil {
+		rollout.PriorityClassName = theirs.PriorityClassName
+	}
+	if theirs.PreStop != nil {
+		rollout.PreStop = theirs.PreStop
+	}
+	rollout.MinReadySeconds = overrideInt(rollout.MinReadySeconds, theirs.MinReadySeconds)
+	rollout.RevisionHistoryLimit = overrideInt(rollout.RevisionHistoryLimit, theirs.RevisionHistoryLimit)
+	rollout.TerminationGracePeriodSeconds = overrideInt(rollout.TerminationGracePeriodSeconds, theirs.TerminationGracePeriodSeconds)
+	return &rollout
+}
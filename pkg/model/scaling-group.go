@@ -0,0 +1,57 @@
+package model
+
+const defaultReplicasKey = "default"
+
+type ScalingGroupSpec struct {
+	Replicas         map[string]int        `json:"replicas,omitempty" yaml:"replicas,omitempty"`
+	Min              *int                  `json:"min,omitempty" yaml:"min,omitempty"`
+	Max              *int                  `json:"max,omitempty" yaml:"max,omitempty"`
+	DisruptionBudget *DisruptionBudgetSpec `json:"disruptionBudget,omitempty" yaml:"disruptionBudget,omitempty"`
+	Autoscaling      *AutoscalingSpec      `json:"autoscaling,omitempty" yaml:"autoscaling,omitempty"`
+	Rollout          *RolloutSpec          `json:"rollout,omitempty" yaml:"rollout,omitempty"`
+}
+
+type DisruptionBudgetSpec struct {
+	Enabled        *bool   `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	MinAvailable   *string `json:"minAvailable,omitempty" yaml:"minAvailable,omitempty"`
+	MaxUnavailable *string `json:"maxUnavailable,omitempty" yaml:"maxUnavailable,omitempty"`
+}
+
+type AutoscalingSpec struct {
+	MinReplicas       *int            `json:"minReplicas,omitempty" yaml:"minReplicas,omitempty"`
+	MaxReplicas       *int            `json:"maxReplicas,omitempty" yaml:"maxReplicas,omitempty"`
+	CpuUtilization    *int            `json:"cpuUtilization,omitempty" yaml:"cpuUtilization,omitempty"`
+	MemoryUtilization *int            `json:"memoryUtilization,omitempty" yaml:"memoryUtilization,omitempty"`
+	Metrics           []PodsMetric    `json:"metrics,omitempty" yaml:"metrics,omitempty"`
+	Triggers          []ScalerTrigger `json:"triggers,omitempty" yaml:"triggers,omitempty"`
+}
+
+// PodsMetric is a custom per pod metric served through the custom metrics api
+type PodsMetric struct {
+	Name         string `json:"name" yaml:"name"`
+	AverageValue string `json:"averageValue" yaml:"averageValue"`
+}
+
+// ScalerTrigger is a KEDA trigger such as kafka or cron, its metadata is passed to KEDA as is
+type ScalerTrigger struct {
+	Type     string            `json:"type" yaml:"type"`
+	Metadata map[string]string `json:"metadata" yaml:"metadata"`
+}
+
+// EventDriven reports whether the autoscaling needs a KEDA ScaledObject rather than a HorizontalPodAutoscaler
+func (as *AutoscalingSpec) EventDriven() bool {
+	return len(as.Triggers) > 0
+}
+
+// ReplicasFor returns the replica count declared for the first of the environment names, falling back to the default entry and then to 1
+func (sg *ScalingGroupSpec) ReplicasFor(envNames []string) int {
+	for _, envName := range envNames {
+		if replicas, ok := sg.Replicas[envName]; ok {
+			return replicas
+		}
+	}
+	if replicas, ok := sg.Replicas[defaultReplicasKey]; ok {
+		return replicas
+	}
+	return 1
+}
@@ -0,0 +1,47 @@
+package nodepool
+
+import (
+	"github.com/skhatri/shores/pkg/applog"
+	"github.com/skhatri/shores/pkg/environment"
+	"github.com/skhatri/shores/pkg/functions"
+	"github.com/skhatri/shores/pkg/model"
+	"github.com/skhatri/shores/pkg/selector"
+)
+
+type NodePool struct {
+	Kind     string       `json:"kind" yaml:"kind"`
+	Metadata Metadata     `json:"metadata" yaml:"metadata"`
+	Spec     NodePoolSpec `json:"spec" yaml:"spec"`
+}
+
+type Metadata struct {
+	Name string `json:"name" yaml:"name"`
+}
+
+type NodePoolSpec struct {
+	Selector selector.Selector  `json:"selector" yaml:"selector"`
+	Pool     model.NodePoolSpec `json:",inline" yaml:",inline"`
+}
+
+//LoadNodePools keeps, for every workload target, the most specific pool whose selector matches the context
+func LoadNodePools(files []string, ctx environment.Context) map[string]model.NodePoolSpec {
+	errors := make([]string, 0)
+	pools := make(map[string]model.NodePoolSpec, 0)
+	ranking := selector.NewRanking("node pool", ctx)
+	for _, file := range files {
+		nodePool := NodePool{}
+		err := functions.UnmarshalFile(file, &nodePool)
+		if err != nil {
+			errors = append(errors, err.Error())
+			continue
+		}
+		if nodePool.Kind != "NodePool" || !ranking.Accept(nodePool.Metadata.Name, file, nodePool.Spec.Selector) {
+			continue
+		}
+		pools[nodePool.Metadata.Name] = nodePool.Spec.Pool
+	}
+	if len(errors) > 0 {
+		applog.Tag("load-node-pools").Error("errors while loading node pools: %s", errors)
+	}
+	return pools
+}
@@ -0,0 +1,56 @@
+package preprocess
+
+import (
+	"errors"
+	"fmt"
+	"github.com/skhatri/shores/pkg/model"
+)
+
+// createAutoscaling resolves the autoscaling of the workload, falling back to the scaling group, with replica bounds defaulted
+func createAutoscaling(workload *model.WorkloadSpec, scalingGroup *model.ScalingGroupSpec, replica int,
+	resources *model.Resources) (*model.AutoscalingSpec, error) {
+	var source *model.AutoscalingSpec
+	if workload != nil && workload.Autoscaling != nil {
+		source = workload.Autoscaling
+	} else if scalingGroup != nil {
+		source = scalingGroup.Autoscaling
+	}
+	if source == nil {
+		return nil, nil
+	}
+	autoscaling := *source
+	if autoscaling.MinReplicas == nil {
+		minReplicas := replica
+		autoscaling.MinReplicas = &minReplicas
+	}
+	if autoscaling.MaxReplicas == nil && scalingGroup != nil {
+		autoscaling.MaxReplicas = scalingGroup.Max
+	}
+	if autoscaling.MaxReplicas == nil {
+		return nil, errors.New("autoscaling requires maxReplicas")
+	}
+	if *autoscaling.MinReplicas < 1 && !autoscaling.EventDriven() {
+		return nil, errors.New(fmt.Sprintf("autoscaling minReplicas [%d] must be at least 1, only event driven autoscaling scales to zero",
+			*autoscaling.MinReplicas))
+	}
+	if *autoscaling.MinReplicas > *autoscaling.MaxReplicas {
+		return nil, errors.New(fmt.Sprintf("autoscaling minReplicas [%d] exceeds maxReplicas [%d]",
+			*autoscaling.MinReplicas, *autoscaling.MaxReplicas))
+	}
+	if autoscaling.CpuUtilization == nil && autoscaling.MemoryUtilization == nil &&
+		len(autoscaling.Metrics) == 0 && len(autoscaling.Triggers) == 0 {
+		return nil, errors.New("autoscaling requires at least one utilisation target, metric or trigger")
+	}
+	if autoscaling.CpuUtilization != nil && (resources == nil || resources.Requests == nil || resources.Requests.Cpu == nil) {
+		return nil, errors.New("cpu utilisation autoscaling requires a cpu request")
+	}
+	if autoscaling.MemoryUtilization != nil && (resources == nil || resources.Requests == nil || resources.Requests.Memory == nil) {
+		return nil, errors.New("memory utilisation autoscaling requires a memory request")
+	}
+	for _, trigger := range autoscaling.Triggers {
+		if trigger.Type == "" {
+			return nil, errors.New("autoscaling trigger requires a type")
+		}
+	}
+	return &autoscaling, nil
+}
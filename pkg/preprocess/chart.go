@@ -0,0 +1,28 @@
+package preprocess
+
+import (
+	"errors"
+	"fmt"
+	"github.com/skhatri/shores/pkg/model"
+)
+
+const chartTypeApplication = "application"
+
+// createChartInfo fills the chart metadata except the version, which depends on the rendered chart
+func createChartInfo(spec model.AppSpec, releaseSpec model.ReleaseSpec, teams map[string]model.TeamSpec) (model.ChartInfo, error) {
+	chart := model.ChartInfo{
+		Type:     chartTypeApplication,
+		Keywords: spec.Keywords,
+	}
+	if releaseSpec.Version != nil {
+		chart.AppVersion = *releaseSpec.Version
+	}
+	if spec.Team != nil {
+		team, ok := teams[*spec.Team]
+		if !ok {
+			return chart, errors.New(fmt.Sprintf("app: [%s], error: [team %s is not defined]", spec.Name, *spec.Team))
+		}
+		chart.Maintainers = team.Maintainers
+	}
+	return chart, nil
+}
@@ -0,0 +1,62 @@
+package preprocess
+
+import (
+	"errors"
+	"fmt"
+	"github.com/skhatri/shores/pkg/model"
+	"strconv"
+	"strings"
+)
+
+// createDisruptionBudget resolves the pod disruption budget of the workload, falling back to the scaling group.
+// The replica count is the one the scaling group declares for the environment unless the app pins its own,
+// single replica workloads never get one as it would block node drains
+func createDisruptionBudget(workload *model.WorkloadSpec, scalingGroup *model.ScalingGroupSpec, replica int,
+	autoscaling *model.AutoscalingSpec) (*model.DisruptionBudgetSpec, error) {
+	var source *model.DisruptionBudgetSpec
+	if workload != nil && workload.DisruptionBudget != nil {
+		source = workload.DisruptionBudget
+	} else if scalingGroup != nil {
+		source = scalingGroup.DisruptionBudget
+	}
+	if source != nil && source.Enabled != nil && !*source.Enabled {
+		return nil, nil
+	}
+	if autoscaling != nil {
+		replica = *autoscaling.MinReplicas
+	}
+	if replica <= 1 {
+		return nil, nil
+	}
+	budget := model.DisruptionBudgetSpec{}
+	if source != nil {
+		budget = *source
+	}
+	if budget.MinAvailable != nil && budget.MaxUnavailable != nil {
+		return nil, errors.New("disruption budget accepts only one of minAvailable and maxUnavailable")
+	}
+	if budget.MinAvailable == nil && budget.MaxUnavailable == nil {
+		maxUnavailable := replica / 4
+		if maxUnavailable < 1 {
+			maxUnavailable = 1
+		}
+		value := strconv.Itoa(maxUnavailable)
+		budget.MaxUnavailable = &value
+	}
+	if budget.MinAvailable != nil && !strings.HasSuffix(*budget.MinAvailable, "%") {
+		minAvailable, err := strconv.Atoi(*budget.MinAvailable)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("invalid disruption budget minAvailable [%s]", *budget.MinAvailable))
+		}
+		if minAvailable >= replica {
+			return nil, errors.New(fmt.Sprintf("disruption budget minAvailable [%d] leaves no pod of [%d] replicas evictable",
+				minAvailable, replica))
+		}
+	}
+	if budget.MaxUnavailable != nil && !strings.HasSuffix(*budget.MaxUnavailable, "%") {
+		if _, err := strconv.Atoi(*budget.MaxUnavailable); err != nil {
+			return nil, errors.New(fmt.Sprintf("invalid disruption budget maxUnavailable [%s]", *budget.MaxUnavailable))
+		}
+	}
+	return &budget, nil
+}
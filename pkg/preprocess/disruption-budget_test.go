@@ -0,0 +1,38 @@
+package preprocess
+
+import (
+	"github.com/skhatri/shores/pkg/environment"
+	"testing"
+)
+
+func TestStackOnMicroservicesGetsBudgetInProd(t *testing.T) {
+	tests := []struct {
+		name           string
+		ctx            environment.Context
+		minReplicas    int
+		maxUnavailable string
+	}{
+		{"dev runs a single replica without a budget", devContext, 1, ""},
+		{"prod runs three replicas with a budget", prodContext, 3, "1"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			deployable := validateTestApp(t, "stack-microservices", test.ctx)
+			if deployable.Autoscaling == nil || *deployable.Autoscaling.MinReplicas != test.minReplicas {
+				t.Fatalf("autoscaling = %+v, want minReplicas %d", deployable.Autoscaling, test.minReplicas)
+			}
+			if test.maxUnavailable == "" {
+				if deployable.DisruptionBudget != nil {
+					t.Errorf("got a disruption budget %+v", deployable.DisruptionBudget)
+				}
+				return
+			}
+			if deployable.DisruptionBudget == nil || deployable.DisruptionBudget.MaxUnavailable == nil {
+				t.Fatalf("disruption budget = %+v, want maxUnavailable %s", deployable.DisruptionBudget, test.maxUnavailable)
+			}
+			if *deployable.DisruptionBudget.MaxUnavailable != test.maxUnavailable {
+				t.Errorf("maxUnavailable = %s, want %s", *deployable.DisruptionBudget.MaxUnavailable, test.maxUnavailable)
+			}
+		})
+	}
+}
@@ -3,82 +3,145 @@ package preprocess
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/skhatri/shores/pkg/applog"
+	"github.com/skhatri/shores/pkg/datamap"
 	"github.com/skhatri/shores/pkg/environment"
 	"github.com/skhatri/shores/pkg/model"
 	"strings"
 )
 
-func enrichAppSpecification(spec model.AppSpec, envLookupData map[string]map[string]string,
-	resourceLookupData map[string]model.Resources) model.Deployable {
+const (
+	KindDeployment = "Deployment"
+	KindJob        = "Job"
+)
+
+type LookupData struct {
+	Env       map[string]map[string]string
+	Resources map[string]model.Resources
+	Mixins    map[string]model.MixinTemplate
+	DataMaps  map[string]map[string]string
+	Globals   map[string]string
+
+	ScalingGroups map[string]model.ScalingGroupSpec
+	NodePools     map[string]model.NodePoolSpec
+	Teams         map[string]model.TeamSpec
+
+	Context environment.Context
+}
 
-	targetInfo := createTargetInfo(spec)
-	healthChecks := createChecks(spec.Service)
+func enrichAppSpecification(spec model.AppSpec, lookup LookupData) (*model.Deployable, error) {
+	kind, kindErr := appKind(spec.Kind)
+	if kindErr != nil {
+		return nil, errors.New(fmt.Sprintf("app: [%s], error: [%v]", spec.Name, kindErr))
+	}
+
+	targetInfo, scalingGroup, targetErr := createTargetInfo(spec, lookup.ScalingGroups, lookup.NodePools, lookup.Context)
+	if targetErr != nil {
+		return nil, errors.New(fmt.Sprintf("app: [%s], error: [%v]", spec.Name, targetErr))
+	}
+	probes, probeErr := createProbes(spec)
+	if probeErr != nil {
+		return nil, errors.New(fmt.Sprintf("app: [%s], error: [%v]", spec.Name, probeErr))
+	}
 	services := createServices(spec.Service)
-	envData := createEnv(spec.Env, envLookupData)
+	envData := createEnv(spec.Env, lookup.Env)
 	serviceEnabled := len(services) > 0
-	resources := createResources(spec.Resources, resourceLookupData)
+	resources, variants, resourceErr := createResources(spec.Resources, lookup.Resources, lookup.DataMaps, spec.ResourceCombine)
+	if resourceErr != nil {
+		return nil, errors.New(fmt.Sprintf("app: [%s], error: [%v]", spec.Name, resourceErr))
+	}
+	cpu, cpuErr := datamap.Resolve(lookup.DataMaps, "cpu", spec.Cpu)
+	if cpuErr != nil {
+		return nil, errors.New(fmt.Sprintf("app: [%s], error: [%v]", spec.Name, cpuErr))
+	}
+	memory, memoryErr := datamap.Resolve(lookup.DataMaps, "memory", spec.Memory)
+	if memoryErr != nil {
+		return nil, errors.New(fmt.Sprintf("app: [%s], error: [%v]", spec.Name, memoryErr))
+	}
+	resources = applyCompute(resources, cpu, memory)
+	strategy := spec.ResourceLimitStrategy
+	if strategy == nil && resources != nil {
+		strategy = resources.Strategy
+	}
+	resources, strategyErr := applyLimitStrategy(resources, strategy)
+	if strategyErr != nil {
+		return nil, errors.New(fmt.Sprintf("app: [%s], error: [%v]", spec.Name, strategyErr))
+	}
+	validationErr := validateResources(resources)
+	if validationErr != nil {
+		return nil, errors.New(fmt.Sprintf("app: [%s], error: [%v]", spec.Name, validationErr))
+	}
+	autoscaling, autoscalingErr := createAutoscaling(spec.Workload, scalingGroup, targetInfo.Replica, resources)
+	if autoscalingErr != nil {
+		return nil, errors.New(fmt.Sprintf("app: [%s], error: [%v]", spec.Name, autoscalingErr))
+	}
+	disruptionBudget, budgetErr := createDisruptionBudget(spec.Workload, scalingGroup, targetInfo.Replica, autoscaling)
+	if budgetErr != nil {
+		return nil, errors.New(fmt.Sprintf("app: [%s], error: [%v]", spec.Name, budgetErr))
+	}
+	rollout, rolloutErr := createRollout(spec.Workload, scalingGroup)
+	if rolloutErr != nil {
+		return nil, errors.New(fmt.Sprintf("app: [%s], error: [%v]", spec.Name, rolloutErr))
+	}
 	ingress := spec.Ingress
-	return model.Deployable{
+	return &model.Deployable{
+		Kind: kind,
 		Artifact: model.ArtifactInfo{
 			Name:  spec.Name,
 			Image: spec.Image,
 		},
 		Env:                envData,
-		Checks:             healthChecks,
+		Probes:             probes,
 		Target:             targetInfo,
 		Service:            services,
 		ServiceAccountName: spec.ServiceAccount,
 		ServiceEnabled:     serviceEnabled,
 		Resources:          resources,
 		Ingress:            ingress,
-	}
+		QosClass:           qosClass(resources),
+		ResourceVariants:   variants,
+		Scaling:            scalingGroup,
+		Autoscaling:        autoscaling,
+		DisruptionBudget:   disruptionBudget,
+		Rollout:            rollout,
+	}, nil
 }
 
-func createResources(resources []string, data map[string]model.Resources) *model.Resources {
-	var resourceRef = &model.Resources{}
-	if len(resources) == 0 {
-		resources = append(resources, "small")
+// appKind maps the kind of the app spec to the workload kind, an app without one is a deployment
+func appKind(kind string) (string, error) {
+	switch {
+	case kind == "" || strings.EqualFold(kind, KindDeployment):
+		return KindDeployment, nil
+	case strings.EqualFold(kind, KindJob):
+		return KindJob, nil
 	}
+	return "", errors.New(fmt.Sprintf("unknown kind [%s], expected one of %s, %s", kind, strings.ToLower(KindDeployment), strings.ToLower(KindJob)))
+}
 
-	for _, resource := range resources {
-		resourceSpec := data[resource]
-		if resourceSpec.Limits != nil {
-			if resourceSpec.Limits.Cpu != nil {
-				if resourceRef.Limits == nil {
-					resourceRef.Limits = &model.ResourceValue{}
-				}
-				resourceRef.Limits.Cpu = resourceSpec.Limits.Cpu
-			}
-			if resourceSpec.Limits.Memory != nil {
-				if resourceRef.Limits == nil {
-					resourceRef.Limits = &model.ResourceValue{}
-				}
-				resourceRef.Limits.Memory = resourceSpec.Limits.Memory
-			}
-
-		}
-
-		if resourceSpec.Requests != nil {
-			if resourceSpec.Requests.Cpu != nil {
-				if resourceRef.Requests == nil {
-					resourceRef.Requests = &model.ResourceValue{}
-				}
-				resourceRef.Requests.Cpu = resourceSpec.Requests.Cpu
-			}
-			if resourceSpec.Requests.Memory != nil {
-				if resourceRef.Requests == nil {
-					resourceRef.Requests = &model.ResourceValue{}
-				}
-				resourceRef.Requests.Memory = resourceSpec.Requests.Memory
-			}
-		}
+func applyCompute(resources *model.Resources, cpu *string, memory *string) *model.Resources {
+	if cpu == nil && memory == nil {
+		return resources
+	}
+	if resources == nil {
+		resources = &model.Resources{}
+	}
+	if resources.Limits == nil {
+		resources.Limits = &model.ResourceValue{}
+	}
+	if resources.Requests == nil {
+		resources.Requests = &model.ResourceValue{}
+	}
+	if cpu != nil {
+		resources.Limits.Cpu = cpu
+		resources.Requests.Cpu = cpu
 	}
-	if resourceRef.Limits == nil && resourceRef.Requests == nil {
-		return nil
+	if memory != nil {
+		resources.Limits.Memory = memory
+		resources.Requests.Memory = memory
 	}
-	return resourceRef
+	return resources
 }
 
 func createEnv(vars []model.Env, lookupData map[string]map[string]string) map[string]string {
@@ -103,18 +166,6 @@ func createEnv(vars []model.Env, lookupData map[string]map[string]string) map[st
 	return envData
 }
 
-func createChecks(service *model.ServiceSpec) *model.Healthcheck {
-	if service == nil || service.HealthCheckUrl == nil {
-		return nil
-	}
-	url := service.HealthCheckUrl
-	healthCheck := model.Healthcheck{
-		Port: "http",
-		Path: *url,
-	}
-	return &healthCheck
-}
-
 func updateDeploymentArtifact(deploymentSpec *model.Deployable, releaseSpec model.ReleaseSpec) {
 	deploymentSpec.Artifact = model.ArtifactInfo{
 		Name:  releaseSpec.Name,
@@ -153,21 +204,33 @@ func createServices(service *model.ServiceSpec) []model.ServiceInfo {
 	return services
 }
 
-func mergeMixins(spec *model.AppSpec, mixinsData map[string]model.MixinTemplate) {
+func mergeMixins(spec *model.AppSpec, mixinsData map[string]model.MixinTemplate) error {
 	mixins := make([]*model.MixinTemplate, 0)
+	if spec.Stack != nil {
+		stackRef, ok := mixinsData[*spec.Stack]
+		if !ok {
+			return errors.New(fmt.Sprintf("app: [%s], error: [stack %s not found]", spec.Name, *spec.Stack))
+		}
+		mixins = append(mixins, &stackRef)
+	}
 	for _, mixinName := range spec.Mixins {
-		mixinRef := mixinsData[mixinName]
+		mixinRef, ok := mixinsData[mixinName]
+		if !ok {
+			return errors.New(fmt.Sprintf("app: [%s], error: [mixin %s not found]", spec.Name, mixinName))
+		}
 		mixins = append(mixins, &mixinRef)
 	}
+	lockErr := checkLockedFields(spec, mixins)
+	if lockErr != nil {
+		return lockErr
+	}
 	mixinTemplate := model.ReduceTemplates(mixins)
 
 	if mixinTemplate != nil {
 		if spec.Service == nil {
 			spec.Service = mixinTemplate.Service
 		}
-		if spec.Workload == nil {
-			spec.Workload = mixinTemplate.Workload
-		}
+		spec.Workload = model.MergeWorkload(mixinTemplate.Workload, spec.Workload)
 		if spec.SecurityContext == nil {
 			spec.SecurityContext = mixinTemplate.SecurityContext
 		}
@@ -184,58 +247,129 @@ func mergeMixins(spec *model.AppSpec, mixinsData map[string]model.MixinTemplate)
 		if spec.Args == nil {
 			spec.Args = mixinTemplate.Args
 		}
+		if spec.Cpu == nil {
+			spec.Cpu = mixinTemplate.Cpu
+		}
+		if spec.Memory == nil {
+			spec.Memory = mixinTemplate.Memory
+		}
+		if spec.ResourceLimitStrategy == nil {
+			spec.ResourceLimitStrategy = mixinTemplate.ResourceLimitStrategy
+		}
+		if spec.ResourceCombine == nil {
+			spec.ResourceCombine = mixinTemplate.ResourceCombine
+		}
+		spec.Probes = model.MergeProbes(mixinTemplate.Probes, spec.Probes)
+		spec.Env = model.MergeEnv(mixinTemplate.Env, spec.Env)
+	}
+	return nil
+}
+
+func checkLockedFields(spec *model.AppSpec, mixins []*model.MixinTemplate) error {
+	specFields := map[string]bool{
+		"secrets":         spec.Secrets != nil,
+		"sidecar":         spec.Sidecar != nil,
+		"service":         spec.Service != nil,
+		"workload":        spec.Workload != nil,
+		"resources":       spec.Resources != nil,
+		"securityContext": spec.SecurityContext != nil,
+		"args":            spec.Args != nil,
+		"env":             spec.Env != nil,
+		"cpu":             spec.Cpu != nil,
+		"memory":          spec.Memory != nil,
+		"probes":          spec.Probes != nil,
+
+		"resource-limit-strategy": spec.ResourceLimitStrategy != nil,
+		"resource-combine":        spec.ResourceCombine != nil,
+	}
+	violations := make([]string, 0)
+	for _, mixinRef := range model.SortBySalience(mixins) {
+		for _, field := range mixinRef.Locked {
+			if specFields[field] {
+				violations = append(violations, fmt.Sprintf("%s (locked by mixin %s)", field, mixinRef.Name))
+			}
+		}
+	}
+	if len(violations) > 0 {
+		return errors.New(fmt.Sprintf("app: [%s], error: [overrides locked fields %s]", spec.Name, strings.Join(violations, ", ")))
 	}
+	return nil
 }
 
-func createTargetInfo(spec model.AppSpec) model.TargetInfo {
+func createTargetInfo(spec model.AppSpec, scalingGroups map[string]model.ScalingGroupSpec,
+	nodePools map[string]model.NodePoolSpec, ctx environment.Context) (model.TargetInfo, *model.ScalingGroupSpec, error) {
 	defaultScaling := "tools"
+	scalingChosen := spec.Workload != nil && spec.Workload.Scaling != nil
 	if spec.Workload == nil {
 		spec.Workload = &model.WorkloadSpec{
 			Target:  "tools",
 			Scaling: &defaultScaling,
 		}
 	}
-	replica := 1
 	if spec.Workload.Scaling == nil {
 		spec.Workload.Scaling = &defaultScaling
 	}
-	if spec.Workload.Scaling != nil {
-		replica = replicaForScalingGroup(*spec.Workload.Scaling)
+	if spec.Workload.Target == "" {
+		spec.Workload.Target = "tools"
+	}
+	scalingGroup, ok := scalingGroups[*spec.Workload.Scaling]
+	if !ok {
+		return model.TargetInfo{}, nil, errors.New(fmt.Sprintf("scaling group [%s] not found", *spec.Workload.Scaling))
+	}
+	replica := scalingGroup.ReplicasFor(ctx.Candidates())
+	if !scalingChosen && spec.Workload.DefaultReplicas != nil {
+		replica = *spec.Workload.DefaultReplicas
+	}
+	if spec.Workload.Replicas != nil {
+		replica = *spec.Workload.Replicas
+	}
+	if scalingGroup.Min != nil && replica < *scalingGroup.Min {
+		return model.TargetInfo{}, nil, errors.New(fmt.Sprintf("replicas [%d] below minimum [%d] of scaling group [%s]",
+			replica, *scalingGroup.Min, *spec.Workload.Scaling))
+	}
+	if scalingGroup.Max != nil && replica > *scalingGroup.Max {
+		return model.TargetInfo{}, nil, errors.New(fmt.Sprintf("replicas [%d] above maximum [%d] of scaling group [%s]",
+			replica, *scalingGroup.Max, *spec.Workload.Scaling))
+	}
+	nodePool, ok := nodePools[spec.Workload.Target]
+	if !ok {
+		return model.TargetInfo{}, nil, errors.New(fmt.Sprintf("node pool [%s] not found", spec.Workload.Target))
 	}
 	targetInfo := model.TargetInfo{
-		NodeSelector: map[string]string{
-			"eks.amazonaws.com/nodegroup": spec.Workload.Target,
-		},
-		Replica: replica,
+		NodeSelector:   nodePool.NodeSelector,
+		Replica:        replica,
+		ScalingGroup:   *spec.Workload.Scaling,
+		Tolerations:    nodePool.Tolerations,
+		Affinity:       nodePool.Affinity,
+		TopologySpread: nodePool.TopologySpread,
 	}
-	return targetInfo
+	return targetInfo, &scalingGroup, nil
 }
 
-func replicaForScalingGroup(group string) int {
-	switch group {
-	case "microservices":
-		if environment.IsProd() {
-			return 3
-		}
-		return 1
-	}
-	return 1
-}
 func ValidateAppSpec(spec model.AppSpec,
-	envLookupData map[string]map[string]string,
-	resourceLookupData map[string]model.Resources,
-	mixinsData map[string]model.MixinTemplate,
+	lookup LookupData,
 	releaseSpec model.ReleaseSpec,
 	task model.Task) (*model.Deployable, error) {
 
-	mergeMixins(&spec, mixinsData)
-	deploymentSpec := enrichAppSpecification(spec, envLookupData, resourceLookupData)
-	updateDeploymentArtifact(&deploymentSpec, releaseSpec)
-	updateLabelsAndAnnotations(&deploymentSpec, releaseSpec, task)
-	updateSecurityContext(&deploymentSpec, spec)
-	updateMount(&deploymentSpec, spec)
-	updateArgs(&deploymentSpec, spec)
-	return &deploymentSpec, nil
+	mixinErr := mergeMixins(&spec, lookup.Mixins)
+	if mixinErr != nil {
+		return nil, mixinErr
+	}
+	deploymentSpec, err := enrichAppSpecification(spec, lookup)
+	if err != nil {
+		return nil, err
+	}
+	chart, chartErr := createChartInfo(spec, releaseSpec, lookup.Teams)
+	if chartErr != nil {
+		return nil, chartErr
+	}
+	deploymentSpec.Chart = chart
+	updateDeploymentArtifact(deploymentSpec, releaseSpec)
+	updateLabelsAndAnnotations(deploymentSpec, releaseSpec, task)
+	updateSecurityContext(deploymentSpec, spec)
+	updateMount(deploymentSpec, spec)
+	updateArgs(deploymentSpec, spec)
+	return deploymentSpec, nil
 }
 
 var (
@@ -258,10 +392,14 @@ func updateSecurityContext(deployable *model.Deployable, appSpec model.AppSpec)
 
 func updateLabelsAndAnnotations(deploymentSpec *model.Deployable, releaseSpec model.ReleaseSpec, task model.Task) {
 	labels := make(map[string]string, 0)
-	labels["helm.sh/chart"] = fmt.Sprintf("%s-%s", releaseSpec.Name, *releaseSpec.Version)
 	labels["app.kubernetes.io/name"] = releaseSpec.Name
 	labels["app.kubernetes.io/instance"] = releaseSpec.Name
-	labels["app.kubernetes.io/managed-by"] = "Helm"
+	if task.HelmChart() {
+		// the helm.sh/chart label is added once the chart version is known
+		labels["app.kubernetes.io/managed-by"] = "Helm"
+	} else {
+		labels["app.kubernetes.io/managed-by"] = "shores"
+	}
 	labels["app.kubernetes.io/version"] = *releaseSpec.Version
 	labels["app.kubernetes.io/release"] = releaseSpec.Name
 
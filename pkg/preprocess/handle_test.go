@@ -0,0 +1,100 @@
+package preprocess
+
+import (
+	"github.com/skhatri/shores/pkg/environment"
+	"github.com/skhatri/shores/pkg/functions"
+	"github.com/skhatri/shores/pkg/model"
+	"github.com/skhatri/shores/pkg/nodepool"
+	"github.com/skhatri/shores/pkg/resource"
+	"github.com/skhatri/shores/pkg/scaling"
+	"github.com/skhatri/shores/pkg/stack"
+	"strings"
+	"testing"
+)
+
+var (
+	devContext  = environment.Context{EnvName: "dev", Tier: "dev"}
+	prodContext = environment.Context{EnvName: "prod", Aliases: []string{"prd"}, Tier: "prod"}
+)
+
+func testLookup(ctx environment.Context) LookupData {
+	return LookupData{
+		Env:           make(map[string]map[string]string),
+		Resources:     resource.LoadResources(functions.ListFiles("testdata/resources", ".yaml"), ctx),
+		Mixins:        stack.LoadStacks([]string{"testdata/stacks.yaml"}),
+		DataMaps:      make(map[string]map[string]string),
+		Globals:       make(map[string]string),
+		ScalingGroups: scaling.LoadScalingGroups(functions.ListFiles("testdata/scaling-groups", ".yaml")),
+		NodePools:     nodepool.LoadNodePools(functions.ListFiles("testdata/node-pools", ".yaml"), ctx),
+		Teams:         make(map[string]model.TeamSpec),
+		Context:       ctx,
+	}
+}
+
+func validateTestApp(t *testing.T, app string, ctx environment.Context) *model.Deployable {
+	t.Helper()
+	deployable, err := loadTestApp(t, app, ctx)
+	if err != nil {
+		t.Fatalf("app %s: %v", app, err)
+	}
+	return deployable
+}
+
+func loadTestApp(t *testing.T, app string, ctx environment.Context) (*model.Deployable, error) {
+	t.Helper()
+	spec := model.AppSpec{}
+	if err := functions.UnmarshalFile("testdata/apps/"+app+".yaml", &spec); err != nil {
+		t.Fatal(err)
+	}
+	version := "1.0"
+	release := model.ReleaseSpec{Name: spec.Name, Image: &spec.Image, Version: &version, Namespace: "default"}
+	return ValidateAppSpec(spec, testLookup(ctx), release, model.Task{})
+}
+
+func TestStackReplicasFollowTheScalingGroup(t *testing.T) {
+	tests := []struct {
+		name     string
+		app      string
+		ctx      environment.Context
+		replicas int
+	}{
+		{"scaling group default", "stack-microservices", devContext, 1},
+		{"scaling group per env", "stack-microservices", prodContext, 3},
+		{"stack default without a scaling group", "stack-only", prodContext, 2},
+		{"replicas pinned by the app", "stack-pinned", prodContext, 4},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			deployable := validateTestApp(t, test.app, test.ctx)
+			if deployable.Target.Replica != test.replicas {
+				t.Errorf("replicas = %d, want %d", deployable.Target.Replica, test.replicas)
+			}
+		})
+	}
+}
+
+func TestUnresolvedTokenIsAnError(t *testing.T) {
+	_, err := loadTestApp(t, "unknown-cpu-token", devContext)
+	if err == nil || !strings.Contains(err.Error(), "could not resolve token [c9] in data map [cpu]") {
+		t.Errorf("error %v, want it to name the token and data map", err)
+	}
+}
+
+func TestAppKind(t *testing.T) {
+	tests := []struct {
+		app  string
+		kind string
+	}{
+		{"stack-microservices", KindDeployment},
+		{"job", KindJob},
+	}
+	for _, test := range tests {
+		if deployable := validateTestApp(t, test.app, devContext); deployable.Kind != test.kind {
+			t.Errorf("app %s has kind %s, want %s", test.app, deployable.Kind, test.kind)
+		}
+	}
+	_, err := loadTestApp(t, "unknown-kind", devContext)
+	if err == nil || !strings.Contains(err.Error(), "unknown kind [cronjob]") {
+		t.Errorf("error %v, want the unknown kind", err)
+	}
+}
@@ -0,0 +1,198 @@
+package preprocess
+
+import (
+	"errors"
+	"fmt"
+	"github.com/skhatri/shores/pkg/applog"
+	"github.com/skhatri/shores/pkg/model"
+	"sort"
+	"strconv"
+)
+
+type probeDefaults struct {
+	initialDelaySeconds int
+	periodSeconds       int
+	timeoutSeconds      int
+	failureThreshold    int
+}
+
+var (
+	livenessDefaults  = probeDefaults{initialDelaySeconds: 30, periodSeconds: 10, timeoutSeconds: 5, failureThreshold: 3}
+	readinessDefaults = probeDefaults{initialDelaySeconds: 10, periodSeconds: 10, timeoutSeconds: 5, failureThreshold: 3}
+	startupDefaults   = probeDefaults{initialDelaySeconds: 0, periodSeconds: 5, timeoutSeconds: 5, failureThreshold: 30}
+)
+
+// createProbes resolves liveness, readiness and startup probes, service.healthCheck provides the http handler of any
+// probe that does not declare its own
+func createProbes(spec model.AppSpec) (*model.ProbesSpec, error) {
+	declared := model.ProbesSpec{}
+	if spec.Probes != nil {
+		declared = *spec.Probes
+	}
+	var healthCheck *model.ProbeSpec
+	ports := make(map[string]int, 0)
+	if spec.Service != nil {
+		if spec.Service.HealthCheckUrl != nil {
+			healthCheck = &model.ProbeSpec{
+				HttpGet: &model.HttpGetAction{Path: *spec.Service.HealthCheckUrl},
+			}
+		}
+		ports = spec.Service.Port
+	}
+	liveness, err := withHandler("liveness", declared.Liveness, healthCheck)
+	if err != nil {
+		return nil, err
+	}
+	readiness, err := withHandler("readiness", declared.Readiness, healthCheck)
+	if err != nil {
+		return nil, err
+	}
+	var startup *model.ProbeSpec
+	if declared.Startup != nil {
+		fallback := readiness
+		if fallback == nil {
+			fallback = liveness
+		}
+		startup, err = withHandler("startup", declared.Startup, fallback)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if liveness == nil && readiness == nil && startup == nil {
+		return nil, nil
+	}
+	probes := model.ProbesSpec{}
+	for _, item := range []struct {
+		name     string
+		probe    *model.ProbeSpec
+		defaults probeDefaults
+		target   **model.ProbeSpec
+	}{
+		{"liveness", liveness, livenessDefaults, &probes.Liveness},
+		{"readiness", readiness, readinessDefaults, &probes.Readiness},
+		{"startup", startup, startupDefaults, &probes.Startup},
+	} {
+		if item.probe == nil {
+			continue
+		}
+		defaults := item.defaults
+		if startup != nil && item.name != "startup" {
+			defaults.initialDelaySeconds = 0
+		}
+		resolved, err := resolveProbe(item.name, item.probe, defaults, ports)
+		if err != nil {
+			return nil, err
+		}
+		*item.target = resolved
+	}
+	return &probes, nil
+}
+
+func withHandler(name string, probe *model.ProbeSpec, fallback *model.ProbeSpec) (*model.ProbeSpec, error) {
+	if probe == nil {
+		return fallback, nil
+	}
+	if probe.HasHandler() {
+		return probe, nil
+	}
+	if fallback == nil {
+		applog.Tag("probes").WithAttribute("probe", name).
+			Info("%s probe only declares timings and no handler is available, skipping", name)
+		return nil, nil
+	}
+	merged := model.MergeProbe(fallback, probe)
+	merged.HttpGet = fallback.HttpGet
+	merged.TcpSocket = fallback.TcpSocket
+	merged.Exec = fallback.Exec
+	merged.Grpc = fallback.Grpc
+	return merged, nil
+}
+
+func resolveProbe(name string, probe *model.ProbeSpec, defaults probeDefaults, ports map[string]int) (*model.ProbeSpec, error) {
+	resolved := *probe
+	handlers := 0
+	if probe.HttpGet != nil {
+		handlers++
+		port, err := resolvePort(name, probe.HttpGet.Port, ports)
+		if err != nil {
+			return nil, err
+		}
+		httpGet := *probe.HttpGet
+		httpGet.Port = port
+		resolved.HttpGet = &httpGet
+	}
+	if probe.TcpSocket != nil {
+		handlers++
+		port, err := resolvePort(name, probe.TcpSocket.Port, ports)
+		if err != nil {
+			return nil, err
+		}
+		resolved.TcpSocket = &model.TcpSocketAction{Port: port}
+	}
+	if probe.Exec != nil {
+		handlers++
+		if len(probe.Exec.Command) == 0 {
+			return nil, errors.New(fmt.Sprintf("%s probe exec handler has no command", name))
+		}
+	}
+	if probe.Grpc != nil {
+		handlers++
+		port, err := resolvePort(name, probe.Grpc.Port, ports)
+		if err != nil {
+			return nil, err
+		}
+		if number, ok := ports[port]; ok {
+			port = strconv.Itoa(number)
+		}
+		grpc := *probe.Grpc
+		grpc.Port = port
+		resolved.Grpc = &grpc
+	}
+	if handlers != 1 {
+		return nil, errors.New(fmt.Sprintf("%s probe must declare exactly one handler, found %d", name, handlers))
+	}
+	resolved.InitialDelaySeconds = defaultInt(probe.InitialDelaySeconds, defaults.initialDelaySeconds)
+	resolved.PeriodSeconds = defaultInt(probe.PeriodSeconds, defaults.periodSeconds)
+	resolved.TimeoutSeconds = defaultInt(probe.TimeoutSeconds, defaults.timeoutSeconds)
+	resolved.FailureThreshold = defaultInt(probe.FailureThreshold, defaults.failureThreshold)
+	resolved.SuccessThreshold = defaultInt(probe.SuccessThreshold, 1)
+	if name != "readiness" && *resolved.SuccessThreshold != 1 {
+		return nil, errors.New(fmt.Sprintf("%s probe successThreshold must be 1", name))
+	}
+	return &resolved, nil
+}
+
+// resolvePort checks a probe port against the declared service ports, an empty port means http or the first declared port
+func resolvePort(name string, port string, ports map[string]int) (string, error) {
+	if port == "" {
+		if _, ok := ports["http"]; ok {
+			return "http", nil
+		}
+		names := make([]string, 0)
+		for portName := range ports {
+			names = append(names, portName)
+		}
+		if len(names) == 0 {
+			return "", errors.New(fmt.Sprintf("%s probe has no port and the service declares none", name))
+		}
+		sort.Strings(names)
+		return names[0], nil
+	}
+	if number, err := strconv.Atoi(port); err == nil {
+		if number < 1 || number > 65535 {
+			return "", errors.New(fmt.Sprintf("%s probe port [%d] is out of range", name, number))
+		}
+		return port, nil
+	}
+	if _, ok := ports[port]; !ok {
+		return "", errors.New(fmt.Sprintf("%s probe port [%s] is not a declared service port", name, port))
+	}
+	return port, nil
+}
+
+func defaultInt(value *int, fallback int) *int {
+	if value != nil {
+		return value
+	}
+	return &fallback
+}
@@ -0,0 +1,185 @@
+package preprocess
+
+import (
+	"errors"
+	"fmt"
+	"github.com/skhatri/shores/pkg/datamap"
+	"github.com/skhatri/shores/pkg/model"
+	"github.com/skhatri/shores/pkg/quantity"
+)
+
+const (
+	CombineLast = "last"
+	CombineMax  = "max"
+	CombineSum  = "sum"
+)
+
+type quantityParser func(value string) (quantity.Quantity, error)
+
+// createResources combines the named resource specs in order, each value is combined with the previous one using the combine mode
+func createResources(resources []string, data map[string]model.Resources, dataMaps map[string]map[string]string,
+	combine *string) (*model.Resources, []model.ResourceVariant, error) {
+	mode := CombineLast
+	if combine != nil {
+		mode = *combine
+	}
+	if mode != CombineLast && mode != CombineMax && mode != CombineSum {
+		return nil, nil, errors.New(fmt.Sprintf("unknown resource-combine [%s], expected one of last, max, sum", mode))
+	}
+	var resourceRef = &model.Resources{}
+	variants := make([]model.ResourceVariant, 0)
+	if len(resources) == 0 {
+		resources = append(resources, "small")
+	}
+
+	for _, resource := range resources {
+		resourceSpec, ok := data[resource]
+		if !ok {
+			return nil, nil, errors.New(fmt.Sprintf("resource [%s] not found", resource))
+		}
+		variants = append(variants, model.ResourceVariant{
+			Name:    resource,
+			Variant: resourceSpec.Variant,
+		})
+		resourceLimits, err := resolveTokens(resourceSpec.Limits, dataMaps)
+		if err != nil {
+			return nil, nil, errors.New(fmt.Sprintf("resource [%s], error: [%v]", resource, err))
+		}
+		resourceRequests, err := resolveTokens(resourceSpec.Requests, dataMaps)
+		if err != nil {
+			return nil, nil, errors.New(fmt.Sprintf("resource [%s], error: [%v]", resource, err))
+		}
+		limits, err := combineValues(resourceRef.Limits, resourceLimits, mode)
+		if err != nil {
+			return nil, nil, err
+		}
+		requests, err := combineValues(resourceRef.Requests, resourceRequests, mode)
+		if err != nil {
+			return nil, nil, err
+		}
+		resourceRef.Limits = limits
+		resourceRef.Requests = requests
+		if resourceSpec.Strategy != nil {
+			resourceRef.Strategy = resourceSpec.Strategy
+		}
+	}
+	if resourceRef.Limits == nil && resourceRef.Requests == nil {
+		return nil, variants, nil
+	}
+	return resourceRef, variants, nil
+}
+
+func resolveTokens(value *model.ResourceValue, dataMaps map[string]map[string]string) (*model.ResourceValue, error) {
+	if value == nil {
+		return nil, nil
+	}
+	cpu, err := datamap.Resolve(dataMaps, "cpu", value.Cpu)
+	if err != nil {
+		return nil, err
+	}
+	memory, err := datamap.Resolve(dataMaps, "memory", value.Memory)
+	if err != nil {
+		return nil, err
+	}
+	return &model.ResourceValue{Cpu: cpu, Memory: memory}, nil
+}
+
+func combineValues(current *model.ResourceValue, next *model.ResourceValue, mode string) (*model.ResourceValue, error) {
+	if next == nil {
+		return current, nil
+	}
+	if current == nil {
+		current = &model.ResourceValue{}
+	}
+	cpu, err := combineQuantity(current.Cpu, next.Cpu, mode, quantity.ParseCpu)
+	if err != nil {
+		return nil, err
+	}
+	memory, err := combineQuantity(current.Memory, next.Memory, mode, quantity.ParseMemory)
+	if err != nil {
+		return nil, err
+	}
+	return &model.ResourceValue{
+		Cpu:    cpu,
+		Memory: memory,
+	}, nil
+}
+
+func combineQuantity(current *string, next *string, mode string, parse quantityParser) (*string, error) {
+	if next == nil {
+		return current, nil
+	}
+	if current == nil || mode == CombineLast {
+		return next, nil
+	}
+	currentValue, err := parse(*current)
+	if err != nil {
+		return nil, err
+	}
+	nextValue, err := parse(*next)
+	if err != nil {
+		return nil, err
+	}
+	combined := quantity.Max(currentValue, nextValue)
+	if mode == CombineSum {
+		combined = currentValue.Add(nextValue)
+	}
+	value := combined.String()
+	return &value, nil
+}
+
+// validateResources normalises every quantity and checks that no request exceeds its limit
+func validateResources(resources *model.Resources) error {
+	if resources == nil {
+		return nil
+	}
+	var err error
+	for _, value := range []*model.ResourceValue{resources.Limits, resources.Requests} {
+		if value == nil {
+			continue
+		}
+		if value.Cpu, err = normalise(value.Cpu, quantity.ParseCpu); err != nil {
+			return err
+		}
+		if value.Memory, err = normalise(value.Memory, quantity.ParseMemory); err != nil {
+			return err
+		}
+	}
+	if resources.Limits == nil || resources.Requests == nil {
+		return nil
+	}
+	if err = checkRequestWithinLimit("cpu", resources.Requests.Cpu, resources.Limits.Cpu, quantity.ParseCpu); err != nil {
+		return err
+	}
+	return checkRequestWithinLimit("memory", resources.Requests.Memory, resources.Limits.Memory, quantity.ParseMemory)
+}
+
+func normalise(value *string, parse quantityParser) (*string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	q, err := parse(*value)
+	if err != nil {
+		return nil, err
+	}
+	normalised := q.String()
+	return &normalised, nil
+}
+
+func checkRequestWithinLimit(name string, request *string, limit *string, parse quantityParser) error {
+	if request == nil || limit == nil {
+		return nil
+	}
+	requestValue, err := parse(*request)
+	if err != nil {
+		return err
+	}
+	limitValue, err := parse(*limit)
+	if err != nil {
+		return err
+	}
+	if requestValue.Cmp(limitValue) > 0 {
+		return errors.New(fmt.Sprintf("%s request [%s] exceeds limit [%s]", name, *request, *limit))
+	}
+	return nil
+}
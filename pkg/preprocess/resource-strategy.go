@@ -0,0 +1,123 @@
+package preprocess
+
+import (
+	"errors"
+	"fmt"
+	"github.com/skhatri/shores/pkg/model"
+	"github.com/skhatri/shores/pkg/quantity"
+)
+
+const (
+	StrategyExact = "exact"
+	StrategyHalf  = "half"
+	StrategyNone  = "none"
+)
+
+const (
+	QosGuaranteed = "Guaranteed"
+	QosBurstable  = "Burstable"
+	QosBestEffort = "BestEffort"
+)
+
+func applyLimitStrategy(resources *model.Resources, strategy *string) (*model.Resources, error) {
+	if resources == nil || strategy == nil || *strategy == "" {
+		return resources, nil
+	}
+	if resources.Limits == nil {
+		resources.Limits = &model.ResourceValue{}
+	}
+	if resources.Requests == nil {
+		resources.Requests = &model.ResourceValue{}
+	}
+	var err error
+	switch *strategy {
+	case StrategyExact:
+		err = deriveValues(resources, 1, 1)
+	case StrategyHalf:
+		err = deriveValues(resources, 1, 2)
+	case StrategyNone:
+		if resources.Requests.Cpu == nil {
+			resources.Requests.Cpu = resources.Limits.Cpu
+		}
+		if resources.Requests.Memory == nil {
+			resources.Requests.Memory = resources.Limits.Memory
+		}
+		resources.Limits = nil
+	default:
+		err = errors.New(fmt.Sprintf("unknown resource-limit-strategy [%s], expected one of exact, half, none", *strategy))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resources.Limits != nil && resources.Limits.Cpu == nil && resources.Limits.Memory == nil {
+		resources.Limits = nil
+	}
+	if resources.Requests.Cpu == nil && resources.Requests.Memory == nil {
+		resources.Requests = nil
+	}
+	return resources, nil
+}
+
+// deriveValues sets requests to numerator/denominator of limits, or limits from requests when no limit is given
+func deriveValues(resources *model.Resources, numerator int64, denominator int64) error {
+	var err error
+	resources.Limits.Cpu, resources.Requests.Cpu, err = derivePair(resources.Limits.Cpu, resources.Requests.Cpu, numerator, denominator,
+		quantity.Quantity.Scale)
+	if err != nil {
+		return err
+	}
+	resources.Limits.Memory, resources.Requests.Memory, err = derivePair(resources.Limits.Memory, resources.Requests.Memory, numerator, denominator,
+		quantity.Quantity.ScaleMemory)
+	return err
+}
+
+// derivePair scales the given value of the pair into the missing one, memory is scaled to whole bytes
+func derivePair(limit *string, request *string, numerator int64, denominator int64,
+	scale func(quantity.Quantity, int64, int64) quantity.Quantity) (*string, *string, error) {
+	if limit != nil {
+		limitValue, err := quantity.Parse(*limit)
+		if err != nil {
+			return nil, nil, err
+		}
+		requestValue := scale(limitValue, numerator, denominator).String()
+		return limit, &requestValue, nil
+	}
+	if request != nil {
+		requestValue, err := quantity.Parse(*request)
+		if err != nil {
+			return nil, nil, err
+		}
+		limitValue := scale(requestValue, denominator, numerator).String()
+		return &limitValue, request, nil
+	}
+	return nil, nil, nil
+}
+
+// qosClass reports the pod QoS class kubernetes will assign to a single container with these resources
+func qosClass(resources *model.Resources) string {
+	if resources == nil || (resources.Limits == nil && resources.Requests == nil) {
+		return QosBestEffort
+	}
+	if resources.Limits == nil || resources.Limits.Cpu == nil || resources.Limits.Memory == nil {
+		return QosBurstable
+	}
+	if resources.Requests != nil {
+		if !sameQuantity(resources.Limits.Cpu, resources.Requests.Cpu) ||
+			!sameQuantity(resources.Limits.Memory, resources.Requests.Memory) {
+			return QosBurstable
+		}
+	}
+	return QosGuaranteed
+}
+
+func sameQuantity(limit *string, request *string) bool {
+	if request == nil {
+		return true
+	}
+	limitValue, lerr := quantity.Parse(*limit)
+	requestValue, rerr := quantity.Parse(*request)
+	if lerr != nil || rerr != nil {
+		return *limit == *request
+	}
+	return limitValue.Milli == requestValue.Milli
+}
@@ -0,0 +1,36 @@
+package preprocess
+
+import (
+	"github.com/skhatri/shores/pkg/model"
+	"testing"
+)
+
+func TestHalfStrategyKeepsWholeBytes(t *testing.T) {
+	tests := []struct {
+		name          string
+		cpu           string
+		memory        string
+		requestCpu    string
+		requestMemory string
+	}{
+		{"odd decimal bytes", "999m", "999", "500m", "500"},
+		{"odd decimal kilobytes", "1", "1001k", "500m", "500500"},
+		{"binary", "500m", "1Gi", "250m", "512Mi"},
+	}
+	strategy := StrategyHalf
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cpu, memory := test.cpu, test.memory
+			resources, err := applyLimitStrategy(&model.Resources{Limits: &model.ResourceValue{Cpu: &cpu, Memory: &memory}}, &strategy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := validateResources(resources); err != nil {
+				t.Fatalf("resources after the half strategy are invalid: %v", err)
+			}
+			if *resources.Requests.Cpu != test.requestCpu || *resources.Requests.Memory != test.requestMemory {
+				t.Errorf("requests = %s/%s, want %s/%s", *resources.Requests.Cpu, *resources.Requests.Memory, test.requestCpu, test.requestMemory)
+			}
+		})
+	}
+}
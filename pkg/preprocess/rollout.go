@@ -0,0 +1,63 @@
+package preprocess
+
+import (
+	"errors"
+	"fmt"
+	"github.com/skhatri/shores/pkg/model"
+)
+
+const (
+	RollingUpdate = "RollingUpdate"
+	Recreate      = "Recreate"
+)
+
+// createRollout overlays the rollout settings of the workload on the defaults of its scaling group
+func createRollout(workload *model.WorkloadSpec, scalingGroup *model.ScalingGroupSpec) (*model.RolloutSpec, error) {
+	var defaults, declared *model.RolloutSpec
+	if scalingGroup != nil {
+		defaults = scalingGroup.Rollout
+	}
+	if workload != nil {
+		declared = workload.Rollout
+	}
+	merged := model.MergeRollout(defaults, declared)
+	if merged == nil {
+		return nil, nil
+	}
+	rollout := *merged
+	if rollout.Strategy == nil {
+		strategy := RollingUpdate
+		rollout.Strategy = &strategy
+	}
+	switch *rollout.Strategy {
+	case RollingUpdate:
+		if rollout.MaxSurge != nil && rollout.MaxUnavailable != nil &&
+			isZero(*rollout.MaxSurge) && isZero(*rollout.MaxUnavailable) {
+			return nil, errors.New("rollout maxSurge and maxUnavailable cannot both be zero")
+		}
+	case Recreate:
+		if declared != nil && (declared.MaxSurge != nil || declared.MaxUnavailable != nil) {
+			return nil, errors.New("rollout maxSurge and maxUnavailable only apply to the RollingUpdate strategy")
+		}
+		// rolling update parameters inherited from the scaling group do not apply to Recreate
+		rollout.MaxSurge = nil
+		rollout.MaxUnavailable = nil
+	default:
+		return nil, errors.New(fmt.Sprintf("unknown rollout strategy [%s], expected one of %s, %s", *rollout.Strategy, RollingUpdate, Recreate))
+	}
+	if rollout.PreStop != nil {
+		if rollout.PreStop.SleepSeconds != nil && len(rollout.PreStop.Exec) > 0 {
+			return nil, errors.New("preStop accepts only one of sleepSeconds and exec")
+		}
+		if rollout.PreStop.SleepSeconds != nil && rollout.TerminationGracePeriodSeconds != nil &&
+			*rollout.PreStop.SleepSeconds >= *rollout.TerminationGracePeriodSeconds {
+			return nil, errors.New(fmt.Sprintf("preStop sleep [%ds] must be shorter than terminationGracePeriodSeconds [%d]",
+				*rollout.PreStop.SleepSeconds, *rollout.TerminationGracePeriodSeconds))
+		}
+	}
+	return &rollout, nil
+}
+
+func isZero(value string) bool {
+	return value == "0" || value == "0%"
+}
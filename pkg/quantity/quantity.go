@@ -0,0 +1,167 @@
+package quantity
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+)
+
+type Format string
+
+const (
+	DecimalSI Format = "DecimalSI"
+	BinarySI  Format = "BinarySI"
+)
+
+//Quantity holds a kubernetes resource quantity as an integer number of milli units
+type Quantity struct {
+	Milli  int64
+	Format Format
+}
+
+// a quantity carries either a decimal exponent such as 1e3 or a unit suffix, never both
+var quantityPattern = regexp.MustCompile("^([+-]?[0-9]*\\.?[0-9]+)(?:([eE][+-]?[0-9]+)|(m|k|M|G|T|P|E|Ki|Mi|Gi|Ti|Pi|Ei))?$")
+
+var multipliers = map[string]*big.Int{
+	"m":  big.NewInt(1),
+	"":   big.NewInt(1000),
+	"k":  pow(1000, 2),
+	"M":  pow(1000, 3),
+	"G":  pow(1000, 4),
+	"T":  pow(1000, 5),
+	"P":  pow(1000, 6),
+	"E":  pow(1000, 7),
+	"Ki": new(big.Int).Mul(big.NewInt(1000), pow(1024, 1)),
+	"Mi": new(big.Int).Mul(big.NewInt(1000), pow(1024, 2)),
+	"Gi": new(big.Int).Mul(big.NewInt(1000), pow(1024, 3)),
+	"Ti": new(big.Int).Mul(big.NewInt(1000), pow(1024, 4)),
+	"Pi": new(big.Int).Mul(big.NewInt(1000), pow(1024, 5)),
+	"Ei": new(big.Int).Mul(big.NewInt(1000), pow(1024, 6)),
+}
+
+var binarySuffixes = []string{"Ei", "Pi", "Ti", "Gi", "Mi", "Ki"}
+var decimalSuffixes = []string{"E", "P", "T", "G", "M", "k"}
+
+func pow(base int64, exp int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(base), big.NewInt(int64(exp)), nil)
+}
+
+func Parse(value string) (Quantity, error) {
+	parts := quantityPattern.FindStringSubmatch(value)
+	if parts == nil {
+		return Quantity{}, errors.New(fmt.Sprintf("invalid quantity [%s]", value))
+	}
+	number, ok := new(big.Rat).SetString(parts[1] + parts[2])
+	if !ok {
+		return Quantity{}, errors.New(fmt.Sprintf("invalid quantity [%s]", value))
+	}
+	milli := new(big.Rat).Mul(number, new(big.Rat).SetInt(multipliers[parts[3]]))
+	rounded := ceil(milli)
+	if !rounded.IsInt64() {
+		return Quantity{}, errors.New(fmt.Sprintf("quantity [%s] is out of range", value))
+	}
+	format := DecimalSI
+	if len(parts[3]) == 2 {
+		format = BinarySI
+	}
+	return Quantity{Milli: rounded.Int64(), Format: format}, nil
+}
+
+func ceil(r *big.Rat) *big.Int {
+	quotient, remainder := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	if remainder.Sign() > 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+	return quotient
+}
+
+//Scale multiplies the quantity by numerator/denominator, rounding up to milli units
+func (q Quantity) Scale(numerator int64, denominator int64) Quantity {
+	return q.scale(numerator, denominator, 1)
+}
+
+// ScaleMemory multiplies the quantity by numerator/denominator, rounding up to whole bytes whatever the format
+func (q Quantity) ScaleMemory(numerator int64, denominator int64) Quantity {
+	return q.scale(numerator, denominator, 1000)
+}
+
+func (q Quantity) scale(numerator int64, denominator int64, unit int64) Quantity {
+	scaled := new(big.Rat).SetFrac(big.NewInt(q.Milli*numerator), big.NewInt(denominator*unit))
+	return Quantity{Milli: ceil(scaled).Int64() * unit, Format: q.Format}
+}
+
+func (q Quantity) String() string {
+	if q.Milli == 0 {
+		return "0"
+	}
+	if q.Milli%1000 != 0 {
+		return fmt.Sprintf("%dm", q.Milli)
+	}
+	units := q.Milli / 1000
+	suffixes := decimalSuffixes
+	if q.Format == BinarySI {
+		suffixes = binarySuffixes
+	}
+	for _, suffix := range suffixes {
+		divisor := new(big.Int).Div(multipliers[suffix], big.NewInt(1000)).Int64()
+		if units%divisor == 0 {
+			return fmt.Sprintf("%d%s", units/divisor, suffix)
+		}
+	}
+	return fmt.Sprintf("%d", units)
+}
+
+// ParseCpu parses a cpu quantity, cpu is always rendered in decimal form such as 500m or 2
+func ParseCpu(value string) (Quantity, error) {
+	q, err := Parse(value)
+	if err != nil {
+		return q, errors.New(fmt.Sprintf("invalid cpu quantity [%s]", value))
+	}
+	if q.Milli < 0 {
+		return q, errors.New(fmt.Sprintf("cpu quantity [%s] must not be negative", value))
+	}
+	return Quantity{Milli: q.Milli, Format: DecimalSI}, nil
+}
+
+// ParseMemory parses a memory quantity and rejects fractions of a byte
+func ParseMemory(value string) (Quantity, error) {
+	q, err := Parse(value)
+	if err != nil {
+		return q, errors.New(fmt.Sprintf("invalid memory quantity [%s]", value))
+	}
+	if q.Milli < 0 {
+		return q, errors.New(fmt.Sprintf("memory quantity [%s] must not be negative", value))
+	}
+	if q.Milli%1000 != 0 {
+		return q, errors.New(fmt.Sprintf("memory quantity [%s] must be a whole number of bytes", value))
+	}
+	return q, nil
+}
+
+// Cmp returns -1, 0 or 1 when q is less than, equal to or greater than other
+func (q Quantity) Cmp(other Quantity) int {
+	if q.Milli < other.Milli {
+		return -1
+	}
+	if q.Milli > other.Milli {
+		return 1
+	}
+	return 0
+}
+
+// Add sums two quantities, keeping the binary format if either side used it
+func (q Quantity) Add(other Quantity) Quantity {
+	format := q.Format
+	if other.Format == BinarySI {
+		format = BinarySI
+	}
+	return Quantity{Milli: q.Milli + other.Milli, Format: format}
+}
+
+func Max(a Quantity, b Quantity) Quantity {
+	if b.Cmp(a) > 0 {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,42 @@
+package quantity
+
+import "testing"
+
+func TestScale(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		numerator   int64
+		denominator int64
+		memory      bool
+		expects     string
+	}{
+		{"half of cpu", "500m", 1, 2, false, "250m"},
+		{"half of an odd milli cpu rounds up", "999m", 1, 2, false, "500m"},
+		{"half of a core", "1", 1, 2, false, "500m"},
+		{"half of binary memory", "1Gi", 1, 2, true, "512Mi"},
+		{"half of odd binary bytes rounds up", "1023", 1, 2, true, "512"},
+		{"half of decimal memory", "1G", 1, 2, true, "500M"},
+		{"half of odd decimal bytes rounds up", "999", 1, 2, true, "500"},
+		{"half of odd decimal kilobytes", "1001k", 1, 2, true, "500500"},
+		{"double of decimal memory", "999", 2, 1, true, "1998"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := Parse(test.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			scaled := q.Scale(test.numerator, test.denominator)
+			if test.memory {
+				scaled = q.ScaleMemory(test.numerator, test.denominator)
+				if _, err := ParseMemory(scaled.String()); err != nil {
+					t.Errorf("scaled memory %s is not a valid memory quantity: %v", scaled, err)
+				}
+			}
+			if scaled.String() != test.expects {
+				t.Errorf("got %s, want %s", scaled, test.expects)
+			}
+		})
+	}
+}
@@ -1,8 +1,12 @@
 package resource
 
 import (
+	"fmt"
+	"github.com/skhatri/shores/pkg/applog"
+	"github.com/skhatri/shores/pkg/environment"
 	"github.com/skhatri/shores/pkg/functions"
 	"github.com/skhatri/shores/pkg/model"
+	"github.com/skhatri/shores/pkg/selector"
 )
 
 type ResourceKind struct {
@@ -16,23 +20,34 @@ type Metadata struct {
 }
 
 type ResourceDef struct {
-	Selector map[string]string `json:"selector" yaml:"selector"`
+	Selector selector.Selector `json:"selector" yaml:"selector"`
+	Strategy *string           `json:"resource-limit-strategy" yaml:"resource-limit-strategy"`
 	Data     model.Resources   `json:"data" yaml:"data"`
 }
 
-func LoadResources(files []string) map[string]model.Resources {
-	errors := make([]error, 0)
+// LoadResources keeps, for every resource name, the most specific variant whose selector matches the context
+func LoadResources(files []string, ctx environment.Context) map[string]model.Resources {
+	errors := make([]string, 0)
 	resources := make(map[string]model.Resources, 0)
+	ranking := selector.NewRanking("resource", ctx)
 	for _, file := range files {
 		resourceKind := ResourceKind{}
 		err := functions.UnmarshalFile(file, &resourceKind)
 		if err != nil {
-			errors = append(errors, err)
+			errors = append(errors, err.Error())
+			continue
 		}
-		if resourceKind.Kind != "Resource" {
+		name := resourceKind.Metadata.Name
+		if resourceKind.Kind != "Resource" || !ranking.Accept(name, file, resourceKind.Spec.Selector) {
 			continue
 		}
-		resources[resourceKind.Metadata.Name] = resourceKind.Spec.Data
+		data := resourceKind.Spec.Data
+		data.Strategy = resourceKind.Spec.Strategy
+		data.Variant = fmt.Sprintf("%s %s", file, selector.Describe(resourceKind.Spec.Selector))
+		resources[name] = data
+	}
+	if len(errors) > 0 {
+		applog.Tag("load-resources").Error("errors while loading resources: %s", errors)
 	}
 	return resources
 }
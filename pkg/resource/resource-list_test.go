@@ -0,0 +1,39 @@
+package resource
+
+import (
+	"github.com/skhatri/shores/pkg/environment"
+	"github.com/skhatri/shores/pkg/functions"
+	"testing"
+)
+
+func TestLoadResourcesRecordsTheVariant(t *testing.T) {
+	tests := []struct {
+		name     string
+		ctx      environment.Context
+		variant  string
+		strategy string
+	}{
+		{"default variant", environment.Context{EnvName: "dev"}, "testdata/1-small.yaml {}", ""},
+		{"policy variant, skipping the broken file and other kinds", environment.Context{EnvName: "alpha", Policies: []string{"prod-like"}},
+			"testdata/2-small-prod.yaml {POLICY=prod-like}", "half"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resources := LoadResources(functions.ListFiles("testdata", ".yaml"), test.ctx)
+			small, ok := resources["small"]
+			if !ok {
+				t.Fatal("resource small was not loaded")
+			}
+			if small.Variant != test.variant {
+				t.Errorf("variant = %s, want %s", small.Variant, test.variant)
+			}
+			strategy := ""
+			if small.Strategy != nil {
+				strategy = *small.Strategy
+			}
+			if strategy != test.strategy {
+				t.Errorf("strategy = %s, want %s", strategy, test.strategy)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package scaling
+
+import (
+	"github.com/skhatri/shores/pkg/applog"
+	"github.com/skhatri/shores/pkg/functions"
+	"github.com/skhatri/shores/pkg/model"
+)
+
+type ScalingGroup struct {
+	Kind     string                 `json:"kind" yaml:"kind"`
+	Metadata Metadata               `json:"metadata" yaml:"metadata"`
+	Spec     model.ScalingGroupSpec `json:"spec" yaml:"spec"`
+}
+
+type Metadata struct {
+	Name string `json:"name" yaml:"name"`
+}
+
+func LoadScalingGroups(files []string) map[string]model.ScalingGroupSpec {
+	errors := make([]string, 0)
+	groups := make(map[string]model.ScalingGroupSpec, 0)
+	for _, file := range files {
+		scalingGroup := ScalingGroup{}
+		err := functions.UnmarshalFile(file, &scalingGroup)
+		if err != nil {
+			errors = append(errors, err.Error())
+			continue
+		}
+		if scalingGroup.Kind != "ScalingGroup" {
+			continue
+		}
+		groups[scalingGroup.Metadata.Name] = scalingGroup.Spec
+	}
+	if len(errors) > 0 {
+		applog.Tag("load-scaling-groups").Error("errors while loading scaling groups: %s", errors)
+	}
+	return groups
+}
@@ -0,0 +1,48 @@
+package selector
+
+import (
+	"github.com/skhatri/shores/pkg/applog"
+	"github.com/skhatri/shores/pkg/environment"
+)
+
+// Ranking keeps track of the variant chosen for every name while the documents of a kind are loaded,
+// a variant is kept when its selector matches the context and is at least as specific as the one kept so far
+type Ranking struct {
+	kind        string
+	ctx         environment.Context
+	specificity map[string]int
+}
+
+func NewRanking(kind string, ctx environment.Context) *Ranking {
+	return &Ranking{
+		kind:        kind,
+		ctx:         ctx,
+		specificity: make(map[string]int, 0),
+	}
+}
+
+// Accept reports whether the document of the file replaces the variant kept for the name,
+// two matching variants of the same specificity are reported and the later one is used
+func (r *Ranking) Accept(name string, file string, selector Selector) bool {
+	if !Matches(selector, r.ctx) {
+		return false
+	}
+	rank := Specificity(selector)
+	if current, exists := r.specificity[name]; exists {
+		if rank < current {
+			return false
+		}
+		if rank == current {
+			applog.Tag("selector").WithAttribute("kind", r.kind).WithAttribute("name", name).WithAttribute("file", file).
+				Error("%s %s has several variants matching %s, using the last one", r.kind, name, Describe(selector))
+		}
+	}
+	r.specificity[name] = rank
+	return true
+}
+
+// Specificity returns the specificity of the variant kept for the name, false when no variant matched
+func (r *Ranking) Specificity(name string) (int, bool) {
+	rank, ok := r.specificity[name]
+	return rank, ok
+}
@@ -0,0 +1,69 @@
+package selector
+
+import (
+	"fmt"
+	"github.com/skhatri/shores/pkg/environment"
+	"testing"
+)
+
+func TestRankingKeepsTheMostSpecificMatch(t *testing.T) {
+	ctx := environment.Context{
+		EnvName:  "alpha",
+		Tier:     "test",
+		Policies: []string{"prod-like"},
+		Location: "hk",
+		Cluster:  "gke-1",
+		Labels:   map[string]string{"team": "payments"},
+	}
+	tests := []struct {
+		name      string
+		selectors []string
+		kept      int
+	}{
+		{"only match", []string{"{ENV_NAME: alpha}"}, 0},
+		{"no match", []string{"{ENV_NAME: prod}"}, -1},
+		{"selected variant wins over the default", []string{"{POLICY: prod-like}", "{}"}, 0},
+		{"default loses to a later variant", []string{"{}", "{ENV_NAME: alpha, LOCATION: hk}"}, 1},
+		{"more keys win in either order", []string{"{ENV_NAME: alpha, CLUSTER: gke-1}", "{ENV_NAME: alpha}"}, 0},
+		{"expressions count like labels", []string{
+			"{TIER: test}",
+			"{TIER: test, matchExpressions: [{key: team, operator: exists}]}",
+			"{POLICY: prod-like}",
+		}, 1},
+		{"a tie keeps the last", []string{"{TIER: test}", "{POLICY: prod-like}"}, 1},
+		{"a tie keeps the last in the other order", []string{"{POLICY: prod-like}", "{TIER: test}"}, 1},
+		{"a more specific document that does not match is ignored", []string{"{TIER: test}", "{TIER: test, CLUSTER: '!gke-*'}"}, 0},
+		{"a broader document after the kept one is ignored", []string{"{TIER: test, LOCATION: hk}", "{}", "{TIER: test}"}, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ranking := NewRanking("test", ctx)
+			kept := -1
+			for i, document := range test.selectors {
+				if ranking.Accept("small", fmt.Sprintf("%d.yaml", i), parse(t, document)) {
+					kept = i
+				}
+			}
+			if kept != test.kept {
+				t.Errorf("kept document %d, want %d", kept, test.kept)
+			}
+			rank, ok := ranking.Specificity("small")
+			if ok != (test.kept >= 0) {
+				t.Fatalf("specificity known = %v, want %v", ok, test.kept >= 0)
+			}
+			if ok && rank != Specificity(parse(t, test.selectors[test.kept])) {
+				t.Errorf("specificity = %d, want that of document %d", rank, test.kept)
+			}
+		})
+	}
+}
+
+func TestRankingKeepsNamesApart(t *testing.T) {
+	ranking := NewRanking("test", prodContext)
+	if !ranking.Accept("small", "a.yaml", parse(t, "{ENV_NAME: prod, CLUSTER: gke-1}")) {
+		t.Fatal("small was not accepted")
+	}
+	if !ranking.Accept("large", "b.yaml", parse(t, "{}")) {
+		t.Errorf("large was rejected because of the rank of small")
+	}
+}
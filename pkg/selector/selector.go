@@ -0,0 +1,189 @@
+package selector
+
+import (
+	"errors"
+	"fmt"
+	"github.com/skhatri/shores/pkg/environment"
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	OpIn           = "in"
+	OpNotIn        = "notin"
+	OpExists       = "exists"
+	OpDoesNotExist = "doesnotexist"
+)
+
+// Selector picks the contexts a spec document applies to, every label and expression has to match.
+// A label value is a glob pattern and a leading ! negates it, expressions are set based as in kubernetes label selectors.
+// A negated value has to be quoted, ENV_NAME: '!prod', as yaml reads an unquoted !prod as a tag.
+// Environment, Mixin, Resource, NodePool and Infrastructure documents select with it
+type Selector struct {
+	Labels      map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	Expressions []Expression      `json:"matchExpressions,omitempty" yaml:"matchExpressions,omitempty"`
+}
+
+type Expression struct {
+	Key      string   `json:"key" yaml:"key"`
+	Operator string   `json:"operator" yaml:"operator"`
+	Values   []string `json:"values,omitempty" yaml:"values,omitempty"`
+}
+
+// UnmarshalYAML reads the labels inline next to an optional matchExpressions list, empty values and malformed patterns are rejected
+func (s *Selector) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	raw := make(map[string]interface{})
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+	expressions := struct {
+		Expressions []Expression `yaml:"matchExpressions"`
+	}{}
+	if err := unmarshal(&expressions); err != nil {
+		return err
+	}
+	for _, expression := range expressions.Expressions {
+		switch strings.ToLower(expression.Operator) {
+		case OpIn, OpNotIn:
+			if len(expression.Values) == 0 {
+				return errors.New(fmt.Sprintf("selector key [%s] needs values for operator [%s]", expression.Key, expression.Operator))
+			}
+		case OpExists, OpDoesNotExist:
+		default:
+			return errors.New(fmt.Sprintf("selector key [%s] has unknown operator [%s]", expression.Key, expression.Operator))
+		}
+		for _, value := range expression.Values {
+			if err := validatePattern(expression.Key, value); err != nil {
+				return err
+			}
+		}
+	}
+	s.Expressions = expressions.Expressions
+	s.Labels = make(map[string]string)
+	for key, value := range raw {
+		if key == "matchExpressions" {
+			continue
+		}
+		pattern := ""
+		if value != nil {
+			pattern = fmt.Sprint(value)
+		}
+		if err := validatePattern(key, strings.TrimPrefix(pattern, "!")); err != nil {
+			return err
+		}
+		s.Labels[key] = pattern
+	}
+	return nil
+}
+
+func validatePattern(key string, pattern string) error {
+	if pattern == "" {
+		return errors.New(fmt.Sprintf("selector key [%s] has an empty value, a negated value has to be quoted as in '!x'", key))
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return errors.New(fmt.Sprintf("selector key [%s] has an invalid pattern [%s], error: [%v]", key, pattern, err))
+	}
+	return nil
+}
+
+// values returns what the context holds for the key, the environment also answers to its aliases and POLICY to every declared policy
+func values(key string, ctx environment.Context) []string {
+	var value string
+	switch key {
+	case "ENV_NAME":
+		if ctx.EnvName == "" {
+			return nil
+		}
+		return append([]string{ctx.EnvName}, ctx.Aliases...)
+	case "TIER":
+		value = ctx.Tier
+	case "POLICY":
+		return ctx.Policies
+	case "LOCATION":
+		value = ctx.Location
+	case "REGION":
+		value = ctx.Region
+	case "CLUSTER":
+		value = ctx.Cluster
+	case "PROVIDER":
+		value = ctx.Provider
+	default:
+		value = ctx.Labels[key]
+	}
+	if value == "" {
+		return nil
+	}
+	return []string{value}
+}
+
+func matchesAny(pattern string, candidates []string) bool {
+	for _, candidate := range candidates {
+		if ok, err := path.Match(pattern, candidate); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (e Expression) matches(ctx environment.Context) bool {
+	candidates := values(e.Key, ctx)
+	switch strings.ToLower(e.Operator) {
+	case OpExists:
+		return len(candidates) > 0
+	case OpDoesNotExist:
+		return len(candidates) == 0
+	}
+	found := false
+	for _, pattern := range e.Values {
+		if matchesAny(pattern, candidates) {
+			found = true
+			break
+		}
+	}
+	if strings.ToLower(e.Operator) == OpNotIn {
+		return !found
+	}
+	return found
+}
+
+// Matches reports whether the selector applies to the context, an empty selector always matches.
+// A negated value matches a context without the key, as notin does
+func Matches(selector Selector, ctx environment.Context) bool {
+	for key, pattern := range selector.Labels {
+		negate := strings.HasPrefix(pattern, "!")
+		if matchesAny(strings.TrimPrefix(pattern, "!"), values(key, ctx)) == negate {
+			return false
+		}
+	}
+	for _, expression := range selector.Expressions {
+		if !expression.matches(ctx) {
+			return false
+		}
+	}
+	return true
+}
+
+// Specificity ranks matching selectors, a document selecting on more attributes is preferred over a broader one
+func Specificity(selector Selector) int {
+	return len(selector.Labels) + len(selector.Expressions)
+}
+
+func Describe(selector Selector) string {
+	if Specificity(selector) == 0 {
+		return "{}"
+	}
+	pairs := make([]string, 0)
+	for k, v := range selector.Labels {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", k, v))
+	}
+	for _, e := range selector.Expressions {
+		expression := fmt.Sprintf("%s %s", e.Key, strings.ToLower(e.Operator))
+		if len(e.Values) > 0 {
+			expression = fmt.Sprintf("%s (%s)", expression, strings.Join(e.Values, ","))
+		}
+		pairs = append(pairs, expression)
+	}
+	sort.Strings(pairs)
+	return fmt.Sprintf("{%s}", strings.Join(pairs, ","))
+}
@@ -0,0 +1,144 @@
+package selector
+
+import (
+	"github.com/skhatri/shores/pkg/environment"
+	"gopkg.in/yaml.v2"
+	"strings"
+	"testing"
+)
+
+var prodContext = environment.Context{
+	EnvName:  "prod",
+	Aliases:  []string{"prd"},
+	Tier:     "prod",
+	Policies: []string{"prod-like"},
+	Location: "hk",
+	Region:   "ap-east-1",
+	Cluster:  "gke-1",
+	Provider: "gcp",
+	Labels:   map[string]string{"team": "payments"},
+}
+
+func parse(t *testing.T, document string) Selector {
+	t.Helper()
+	selector := Selector{}
+	if err := yaml.Unmarshal([]byte(document), &selector); err != nil {
+		t.Fatalf("selector %q: %v", document, err)
+	}
+	return selector
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector string
+		matches  bool
+	}{
+		{"empty selector", "{}", true},
+		{"exact env", "ENV_NAME: prod", true},
+		{"other env", "ENV_NAME: dev", false},
+		{"env alias", "ENV_NAME: prd", true},
+		{"tier", "TIER: prod", true},
+		{"policy", "POLICY: prod-like", true},
+		{"missing policy", "POLICY: pci", false},
+		{"location is the code", "LOCATION: hk", true},
+		{"location is not the region", "LOCATION: ap-east-1", false},
+		{"region", "REGION: ap-east-1", true},
+		{"cluster", "CLUSTER: gke-1", true},
+		{"provider", "PROVIDER: aws", false},
+		{"context label", "team: payments", true},
+		{"other label value", "team: cards", false},
+		{"label the context lacks", "owner: payments", false},
+		{"glob", "CLUSTER: gke-*", true},
+		{"glob on an alias", "ENV_NAME: pr?", true},
+		{"glob without a match", "CLUSTER: eks-*", false},
+		{"character class", "REGION: 'ap-[ew]*'", true},
+		{"negation", "ENV_NAME: '!dev'", true},
+		{"negation of the env", "ENV_NAME: '!prod'", false},
+		{"negation of an alias", "ENV_NAME: '!prd'", false},
+		{"negated glob", "CLUSTER: '!gke-*'", false},
+		{"negation of a missing key", "owner: '!payments'", true},
+		{"every label has to match", "{ENV_NAME: prod, CLUSTER: eks-1}", false},
+		{"in", "matchExpressions: [{key: ENV_NAME, operator: In, values: [dev, prod]}]", true},
+		{"in by alias", "matchExpressions: [{key: ENV_NAME, operator: in, values: [prd]}]", true},
+		{"in by glob", "matchExpressions: [{key: LOCATION, operator: in, values: ['h*']}]", true},
+		{"in without a match", "matchExpressions: [{key: ENV_NAME, operator: in, values: [dev, sit]}]", false},
+		{"in on a missing key", "matchExpressions: [{key: owner, operator: in, values: [payments]}]", false},
+		{"notin", "matchExpressions: [{key: ENV_NAME, operator: NotIn, values: [dev, sit]}]", true},
+		{"notin with a match", "matchExpressions: [{key: TIER, operator: notin, values: [prod]}]", false},
+		{"notin on a missing key", "matchExpressions: [{key: owner, operator: notin, values: [payments]}]", true},
+		{"exists", "matchExpressions: [{key: team, operator: Exists}]", true},
+		{"exists on a missing key", "matchExpressions: [{key: owner, operator: exists}]", false},
+		{"doesnotexist", "matchExpressions: [{key: owner, operator: DoesNotExist}]", true},
+		{"doesnotexist on a present key", "matchExpressions: [{key: CLUSTER, operator: doesnotexist}]", false},
+		{"labels and expressions", "{ENV_NAME: prod, matchExpressions: [{key: team, operator: in, values: [payments]}]}", true},
+		{"failing expression", "{ENV_NAME: prod, matchExpressions: [{key: team, operator: notin, values: [payments]}]}", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Matches(parse(t, test.selector), prodContext); got != test.matches {
+				t.Errorf("Matches(%s) = %v, want %v", test.selector, got, test.matches)
+			}
+		})
+	}
+}
+
+func TestNegationAgreesWithNotIn(t *testing.T) {
+	contexts := []environment.Context{prodContext, {EnvName: "dev"}, {}}
+	for _, ctx := range contexts {
+		for _, key := range []string{"ENV_NAME", "CLUSTER", "owner"} {
+			negated := parse(t, key+": '!prod'")
+			notIn := parse(t, "matchExpressions: [{key: "+key+", operator: notin, values: [prod]}]")
+			if Matches(negated, ctx) != Matches(notIn, ctx) {
+				t.Errorf("key %s on %+v: negation gives %v and notin %v", key, ctx, Matches(negated, ctx), Matches(notIn, ctx))
+			}
+		}
+	}
+}
+
+func TestUnmarshalRejectsInvalidSelectors(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector string
+		message  string
+	}{
+		{"unquoted negation", "ENV_NAME: !prod", "has to be quoted"},
+		{"missing value", "ENV_NAME:", "empty value"},
+		{"empty value", "ENV_NAME: ''", "empty value"},
+		{"bare negation", "ENV_NAME: '!'", "empty value"},
+		{"bad glob", "ENV_NAME: '[abc'", "invalid pattern"},
+		{"bad negated glob", "ENV_NAME: '![abc'", "invalid pattern"},
+		{"bad expression glob", "matchExpressions: [{key: ENV_NAME, operator: in, values: ['[abc']}]", "invalid pattern"},
+		{"unknown operator", "matchExpressions: [{key: ENV_NAME, operator: equals, values: [prod]}]", "unknown operator"},
+		{"in without values", "matchExpressions: [{key: ENV_NAME, operator: in}]", "needs values"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selector := Selector{}
+			err := yaml.Unmarshal([]byte(test.selector), &selector)
+			if err == nil {
+				t.Fatalf("selector %q was accepted", test.selector)
+			}
+			if !strings.Contains(err.Error(), test.message) {
+				t.Errorf("selector %q failed with %q, want it to mention %q", test.selector, err, test.message)
+			}
+		})
+	}
+}
+
+func TestSpecificity(t *testing.T) {
+	tests := []struct {
+		selector    string
+		specificity int
+	}{
+		{"{}", 0},
+		{"ENV_NAME: prod", 1},
+		{"{ENV_NAME: prod, CLUSTER: gke-1}", 2},
+		{"{ENV_NAME: prod, matchExpressions: [{key: team, operator: exists}]}", 2},
+	}
+	for _, test := range tests {
+		if got := Specificity(parse(t, test.selector)); got != test.specificity {
+			t.Errorf("Specificity(%s) = %d, want %d", test.selector, got, test.specificity)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package stack
+
+import "github.com/skhatri/shores/pkg/model"
+
+type StackList struct {
+	Kind   string          `json:"kind" yaml:"kind"`
+	Stacks []StackTemplate `json:"mixin" yaml:"mixin"`
+}
+
+type StackTemplate struct {
+	Name       string            `json:"name" yaml:"name"`
+	Cpu        *string           `json:"cpu" yaml:"cpu"`
+	Memory     *string           `json:"memory" yaml:"memory"`
+	Replicas   *int              `json:"replicas" yaml:"replicas"`
+	Strategy   *string           `json:"resource-limit-strategy" yaml:"resource-limit-strategy"`
+	Salience   int               `json:"salience" yaml:"salience"`
+	Env        map[string]string `json:"env" yaml:"env"`
+	Cmd        []string          `json:"cmd" yaml:"cmd"`
+	Entrypoint []string          `json:"entrypoint" yaml:"entrypoint"`
+	Probes     *model.ProbesSpec `json:"probes" yaml:"probes"`
+}
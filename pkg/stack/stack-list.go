@@ -0,0 +1,76 @@
+package stack
+
+import (
+	"github.com/skhatri/shores/pkg/applog"
+	"github.com/skhatri/shores/pkg/functions"
+	"github.com/skhatri/shores/pkg/model"
+	"sort"
+)
+
+func LoadStacks(files []string) map[string]model.MixinTemplate {
+	errors := make([]string, 0)
+	stacks := make(map[string]model.MixinTemplate, 0)
+	for _, file := range files {
+		stackList := StackList{}
+		err := functions.UnmarshalFile(file, &stackList)
+		if err != nil {
+			errors = append(errors, err.Error())
+			continue
+		}
+		if stackList.Kind != "Stack" {
+			continue
+		}
+		for _, stackTemplate := range stackList.Stacks {
+			stacks[stackTemplate.Name] = toMixinTemplate(stackTemplate)
+		}
+	}
+	if len(errors) > 0 {
+		applog.Tag("load-stacks").Error("errors while loading stack templates: %s", errors)
+	}
+	return stacks
+}
+
+func toMixinTemplate(stackTemplate StackTemplate) model.MixinTemplate {
+	template := model.MixinTemplate{
+		Name:                  stackTemplate.Name,
+		Salience:              stackTemplate.Salience,
+		Cpu:                   stackTemplate.Cpu,
+		Memory:                stackTemplate.Memory,
+		ResourceLimitStrategy: stackTemplate.Strategy,
+		Probes:                stackTemplate.Probes,
+	}
+	// a stack only suggests a replica count, the scaling group of the app decides it per environment
+	if stackTemplate.Replicas != nil {
+		template.Workload = &model.WorkloadSpec{
+			DefaultReplicas: stackTemplate.Replicas,
+		}
+	}
+	if len(stackTemplate.Cmd) > 0 || len(stackTemplate.Entrypoint) > 0 {
+		template.Args = &model.ArgsSpec{
+			Entrypoint: toRefs(stackTemplate.Entrypoint),
+			Command:    toRefs(stackTemplate.Cmd),
+		}
+	}
+	keys := make([]string, 0)
+	for k := range stackTemplate.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		name := k
+		value := stackTemplate.Env[k]
+		template.Env = append(template.Env, model.Env{
+			Name:  &name,
+			Value: &value,
+		})
+	}
+	return template
+}
+
+func toRefs(items []string) []*string {
+	refs := make([]*string, 0)
+	for i := range items {
+		refs = append(refs, &items[i])
+	}
+	return refs
+}
@@ -0,0 +1,13 @@
+package team
+
+import "github.com/skhatri/shores/pkg/model"
+
+type Team struct {
+	Kind     string         `json:"kind" yaml:"kind"`
+	Metadata Metadata       `json:"metadata" yaml:"metadata"`
+	Spec     model.TeamSpec `json:"spec" yaml:"spec"`
+}
+
+type Metadata struct {
+	Name string `json:"name" yaml:"name"`
+}
@@ -0,0 +1,28 @@
+package team
+
+import (
+	"github.com/skhatri/shores/pkg/applog"
+	"github.com/skhatri/shores/pkg/functions"
+	"github.com/skhatri/shores/pkg/model"
+)
+
+func LoadTeams(files []string) map[string]model.TeamSpec {
+	errors := make([]string, 0)
+	teams := make(map[string]model.TeamSpec, 0)
+	for _, file := range files {
+		teamKind := Team{}
+		err := functions.UnmarshalFile(file, &teamKind)
+		if err != nil {
+			errors = append(errors, err.Error())
+			continue
+		}
+		if teamKind.Kind != "Team" {
+			continue
+		}
+		teams[teamKind.Metadata.Name] = teamKind.Spec
+	}
+	if len(errors) > 0 {
+		applog.Tag("load-teams").Error("errors while loading teams: %s", errors)
+	}
+	return teams
+}
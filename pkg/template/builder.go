@@ -0,0 +1,507 @@
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"github.com/skhatri/shores/pkg/k8s"
+	"github.com/skhatri/shores/pkg/model"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// BuildObject creates the typed kubernetes object generated for the template name
+func BuildObject(tName string, deployable *model.Deployable) (interface{}, error) {
+	switch tName {
+	case "ChartTemplate":
+		return buildChart(deployable), nil
+	case "ServiceAccountTemplate":
+		return buildServiceAccount(deployable), nil
+	case "ServiceTemplate":
+		return buildService(deployable), nil
+	case "DeploymentTemplate":
+		return buildDeployment(deployable)
+	case "HorizontalPodAutoscalerTemplate":
+		return buildHorizontalPodAutoscaler(deployable), nil
+	case "ScaledObjectTemplate":
+		return buildScaledObject(deployable), nil
+	case "PodDisruptionBudgetTemplate":
+		return buildPodDisruptionBudget(deployable), nil
+	case "JobTemplate":
+		return buildJob(deployable)
+	}
+	return nil, errors.New(fmt.Sprintf("no builder for template [%s]", tName))
+}
+
+func objectName(deployable *model.Deployable) string {
+	return strings.ToLower(deployable.Artifact.Name)
+}
+
+func objectMeta(deployable *model.Deployable, withAnnotations bool) k8s.ObjectMeta {
+	meta := k8s.ObjectMeta{
+		Name:      objectName(deployable),
+		Namespace: deployable.Namespace,
+		Labels:    deployable.Metadata.Labels,
+	}
+	if withAnnotations {
+		meta.Annotations = deployable.Metadata.Annotations
+	}
+	return meta
+}
+
+func buildChart(deployable *model.Deployable) k8s.Chart {
+	chart := k8s.Chart{
+		ApiVersion:  "v2",
+		Name:        deployable.Artifact.Name,
+		Description: fmt.Sprintf("A Helm chart for Kubernetes %s", deployable.Artifact.Name),
+		Type:        deployable.Chart.Type,
+		Version:     deployable.Chart.Version,
+		AppVersion:  deployable.Chart.AppVersion,
+		Keywords:    deployable.Chart.Keywords,
+	}
+	for _, maintainer := range deployable.Chart.Maintainers {
+		chart.Maintainers = append(chart.Maintainers, k8s.ChartMaintainer{
+			Name:  maintainer.Name,
+			Email: maintainer.Email,
+			Url:   maintainer.Url,
+		})
+	}
+	if deployable.Chart.ContentHash != "" {
+		chart.Annotations = map[string]string{contentHashAnnotation: deployable.Chart.ContentHash}
+	}
+	return chart
+}
+
+func buildServiceAccount(deployable *model.Deployable) k8s.ServiceAccount {
+	return k8s.ServiceAccount{
+		TypeMeta: k8s.TypeMeta{ApiVersion: "v1", Kind: "ServiceAccount"},
+		Metadata: objectMeta(deployable, true),
+	}
+}
+
+func buildService(deployable *model.Deployable) k8s.Service {
+	ports := make([]k8s.ServicePort, 0)
+	for _, port := range deployable.Ports() {
+		ports = append(ports, k8s.ServicePort{
+			Name:       port.Name,
+			Port:       port.Port,
+			TargetPort: k8s.IntOrString(port.Name),
+			Protocol:   port.Protocol,
+		})
+	}
+	return k8s.Service{
+		TypeMeta: k8s.TypeMeta{ApiVersion: "v1", Kind: "Service"},
+		Metadata: objectMeta(deployable, true),
+		Spec: k8s.ServiceSpec{
+			Type:     "ClusterIP",
+			Ports:    ports,
+			Selector: deployable.Metadata.SelectorLabels,
+		},
+	}
+}
+
+func buildDeployment(deployable *model.Deployable) (k8s.Deployment, error) {
+	podSpec, err := buildPodSpec(deployable)
+	if err != nil {
+		return k8s.Deployment{}, err
+	}
+	spec := k8s.DeploymentSpec{
+		Selector: k8s.LabelSelector{MatchLabels: deployable.Metadata.SelectorLabels},
+		Template: k8s.PodTemplateSpec{
+			Metadata: k8s.ObjectMeta{Labels: deployable.Metadata.SelectorLabels},
+			Spec:     *podSpec,
+		},
+	}
+	if deployable.Autoscaling == nil {
+		replicas := deployable.Target.Replica
+		spec.Replicas = &replicas
+	}
+	if rollout := deployable.Rollout; rollout != nil {
+		strategy := &k8s.DeploymentStrategy{}
+		if rollout.Strategy != nil {
+			strategy.Type = *rollout.Strategy
+		}
+		if rollout.MaxSurge != nil || rollout.MaxUnavailable != nil {
+			strategy.RollingUpdate = &k8s.RollingUpdateDeployment{
+				MaxSurge:       k8s.NewIntOrString(rollout.MaxSurge),
+				MaxUnavailable: k8s.NewIntOrString(rollout.MaxUnavailable),
+			}
+		}
+		spec.Strategy = strategy
+		spec.MinReadySeconds = rollout.MinReadySeconds
+		spec.RevisionHistoryLimit = rollout.RevisionHistoryLimit
+	}
+	return k8s.Deployment{
+		TypeMeta: k8s.TypeMeta{ApiVersion: "apps/v1", Kind: "Deployment"},
+		Metadata: objectMeta(deployable, true),
+		Spec:     spec,
+	}, nil
+}
+
+func buildJob(deployable *model.Deployable) (k8s.Job, error) {
+	podSpec, err := buildPodSpec(deployable)
+	if err != nil {
+		return k8s.Job{}, err
+	}
+	podSpec.RestartPolicy = "Never"
+	completions := deployable.Target.Replica
+	if completions < 1 {
+		completions = 1
+	}
+	return k8s.Job{
+		TypeMeta: k8s.TypeMeta{ApiVersion: "batch/v1", Kind: "Job"},
+		Metadata: objectMeta(deployable, true),
+		Spec: k8s.JobSpec{
+			Completions: &completions,
+			Template: k8s.PodTemplateSpec{
+				Metadata: k8s.ObjectMeta{Labels: deployable.Metadata.SelectorLabels},
+				Spec:     *podSpec,
+			},
+		},
+	}, nil
+}
+
+func buildPodSpec(deployable *model.Deployable) (*k8s.PodSpec, error) {
+	container, err := buildContainer(deployable)
+	if err != nil {
+		return nil, err
+	}
+	podSpec := &k8s.PodSpec{
+		ServiceAccountName: objectName(deployable),
+		Containers:         []k8s.Container{*container},
+		NodeSelector:       deployable.Target.NodeSelector,
+		Affinity:           buildAffinity(deployable.Target.Affinity),
+		Tolerations:        buildTolerations(deployable.Target.Tolerations),
+	}
+	if deployable.ServiceAccountName != nil {
+		podSpec.ServiceAccountName = *deployable.ServiceAccountName
+	}
+	if rollout := deployable.Rollout; rollout != nil {
+		podSpec.TerminationGracePeriodSeconds = rollout.TerminationGracePeriodSeconds
+		if rollout.PriorityClassName != nil {
+			podSpec.PriorityClassName = *rollout.PriorityClassName
+		}
+	}
+	if sc := deployable.SecurityContext; sc != nil {
+		runAsUser, err := parseRunAsUser(sc.RunAsUser)
+		if err != nil {
+			return nil, err
+		}
+		podSpec.SecurityContext = &k8s.PodSecurityContext{
+			RunAsNonRoot: sc.RunAsNonRoot,
+			RunAsUser:    runAsUser,
+		}
+	}
+	for _, constraint := range deployable.Target.TopologySpread {
+		whenUnsatisfiable := constraint.WhenUnsatisfiable
+		if whenUnsatisfiable == "" {
+			whenUnsatisfiable = "ScheduleAnyway"
+		}
+		podSpec.TopologySpreadConstraints = append(podSpec.TopologySpreadConstraints, k8s.TopologySpreadConstraint{
+			MaxSkew:           constraint.MaxSkew,
+			TopologyKey:       constraint.TopologyKey,
+			WhenUnsatisfiable: whenUnsatisfiable,
+			LabelSelector:     k8s.LabelSelector{MatchLabels: deployable.Metadata.SelectorLabels},
+		})
+	}
+	for _, mount := range deployable.Mounts {
+		volume := k8s.Volume{Name: mount.Name}
+		if mount.Type == "emptyDir" {
+			volume.EmptyDir = &k8s.EmptyDir{}
+		}
+		podSpec.Volumes = append(podSpec.Volumes, volume)
+	}
+	return podSpec, nil
+}
+
+func buildContainer(deployable *model.Deployable) (*k8s.Container, error) {
+	container := &k8s.Container{
+		Name:            deployable.Artifact.Name,
+		Image:           deployable.Artifact.Image,
+		ImagePullPolicy: "IfNotPresent",
+	}
+	if deployable.Args != nil {
+		container.Command = stringValues(deployable.Args.Entrypoint)
+		container.Args = stringValues(deployable.Args.Command)
+	}
+	if deployable.ServiceEnabled {
+		for _, port := range deployable.Ports() {
+			container.Ports = append(container.Ports, k8s.ContainerPort{
+				Name:          port.Name,
+				ContainerPort: port.Port,
+				Protocol:      port.Protocol,
+			})
+		}
+	}
+	if deployable.Rollout != nil && deployable.Rollout.PreStop != nil {
+		preStop := &k8s.LifecycleHandler{}
+		if deployable.Rollout.PreStop.SleepSeconds != nil {
+			preStop.Sleep = &k8s.SleepAction{Seconds: *deployable.Rollout.PreStop.SleepSeconds}
+		} else {
+			preStop.Exec = &k8s.ExecAction{Command: deployable.Rollout.PreStop.Exec}
+		}
+		container.Lifecycle = &k8s.Lifecycle{PreStop: preStop}
+	}
+	if deployable.Probes != nil {
+		container.LivenessProbe = buildProbe(deployable.Probes.Liveness)
+		container.ReadinessProbe = buildProbe(deployable.Probes.Readiness)
+		container.StartupProbe = buildProbe(deployable.Probes.Startup)
+	}
+	if deployable.Resources != nil {
+		container.Resources = &k8s.ResourceRequirements{
+			Requests: resourceValues(deployable.Resources.Requests),
+			Limits:   resourceValues(deployable.Resources.Limits),
+		}
+	}
+	container.Env = buildEnv(deployable.Env)
+	for _, mount := range deployable.Mounts {
+		container.VolumeMounts = append(container.VolumeMounts, k8s.VolumeMount{
+			Name:      mount.Name,
+			MountPath: mount.Path,
+		})
+	}
+	if sc := deployable.SecurityContext; sc != nil {
+		runAsUser, err := parseRunAsUser(sc.RunAsUser)
+		if err != nil {
+			return nil, err
+		}
+		container.SecurityContext = &k8s.SecurityContext{
+			AllowPrivilegeEscalation: sc.AllowPrivilegeEscalation,
+			ReadOnlyRootFilesystem:   sc.ReadOnlyRootFilesystem,
+			RunAsNonRoot:             sc.RunAsNonRoot,
+			RunAsUser:                runAsUser,
+		}
+	}
+	return container, nil
+}
+
+func buildProbe(probe *model.ProbeSpec) *k8s.Probe {
+	if probe == nil {
+		return nil
+	}
+	built := &k8s.Probe{
+		InitialDelaySeconds: probe.InitialDelaySeconds,
+		PeriodSeconds:       probe.PeriodSeconds,
+		TimeoutSeconds:      probe.TimeoutSeconds,
+		FailureThreshold:    probe.FailureThreshold,
+		SuccessThreshold:    probe.SuccessThreshold,
+	}
+	if probe.HttpGet != nil {
+		built.HttpGet = &k8s.HTTPGetAction{
+			Path:   probe.HttpGet.Path,
+			Port:   k8s.IntOrString(probe.HttpGet.Port),
+			Scheme: probe.HttpGet.Scheme,
+		}
+	}
+	if probe.TcpSocket != nil {
+		built.TcpSocket = &k8s.TCPSocketAction{Port: k8s.IntOrString(probe.TcpSocket.Port)}
+	}
+	if probe.Exec != nil {
+		built.Exec = &k8s.ExecAction{Command: probe.Exec.Command}
+	}
+	if probe.Grpc != nil {
+		built.Grpc = &k8s.GRPCAction{
+			Port:    k8s.IntOrString(probe.Grpc.Port),
+			Service: probe.Grpc.Service,
+		}
+	}
+	return built
+}
+
+func buildAffinity(affinity *model.NodeAffinity) *k8s.Affinity {
+	if affinity == nil {
+		return nil
+	}
+	nodeAffinity := &k8s.NodeAffinity{}
+	if len(affinity.Required) > 0 {
+		expressions := make([]k8s.NodeSelectorRequirement, 0)
+		for _, term := range affinity.Required {
+			expressions = append(expressions, nodeSelectorRequirement(term))
+		}
+		nodeAffinity.Required = &k8s.NodeSelector{
+			NodeSelectorTerms: []k8s.NodeSelectorTerm{{MatchExpressions: expressions}},
+		}
+	}
+	for _, term := range affinity.Preferred {
+		nodeAffinity.Preferred = append(nodeAffinity.Preferred, k8s.PreferredSchedulingTerm{
+			Weight: term.Weight,
+			Preference: k8s.NodeSelectorTerm{
+				MatchExpressions: []k8s.NodeSelectorRequirement{nodeSelectorRequirement(term.NodeSelectorRequirement)},
+			},
+		})
+	}
+	return &k8s.Affinity{NodeAffinity: nodeAffinity}
+}
+
+func nodeSelectorRequirement(term model.NodeSelectorRequirement) k8s.NodeSelectorRequirement {
+	return k8s.NodeSelectorRequirement{
+		Key:      term.Key,
+		Operator: term.Operator,
+		Values:   term.Values,
+	}
+}
+
+func buildTolerations(tolerations []model.Toleration) []k8s.Toleration {
+	built := make([]k8s.Toleration, 0)
+	for _, toleration := range tolerations {
+		operator := toleration.Operator
+		if operator == "" {
+			operator = "Equal"
+		}
+		built = append(built, k8s.Toleration{
+			Key:      toleration.Key,
+			Operator: operator,
+			Value:    toleration.Value,
+			Effect:   toleration.Effect,
+		})
+	}
+	return built
+}
+
+func buildHorizontalPodAutoscaler(deployable *model.Deployable) k8s.HorizontalPodAutoscaler {
+	autoscaling := deployable.Autoscaling
+	metrics := make([]k8s.MetricSpec, 0)
+	if autoscaling.CpuUtilization != nil {
+		metrics = append(metrics, utilizationMetric("cpu", autoscaling.CpuUtilization))
+	}
+	if autoscaling.MemoryUtilization != nil {
+		metrics = append(metrics, utilizationMetric("memory", autoscaling.MemoryUtilization))
+	}
+	for _, metric := range autoscaling.Metrics {
+		metrics = append(metrics, k8s.MetricSpec{
+			Type: "Pods",
+			Pods: &k8s.PodsMetricSource{
+				Metric: k8s.MetricIdentifier{Name: metric.Name},
+				Target: k8s.MetricTarget{Type: "AverageValue", AverageValue: metric.AverageValue},
+			},
+		})
+	}
+	maxReplicas := 0
+	if autoscaling.MaxReplicas != nil {
+		maxReplicas = *autoscaling.MaxReplicas
+	}
+	return k8s.HorizontalPodAutoscaler{
+		TypeMeta: k8s.TypeMeta{ApiVersion: "autoscaling/v2", Kind: "HorizontalPodAutoscaler"},
+		Metadata: objectMeta(deployable, false),
+		Spec: k8s.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: deploymentReference(deployable),
+			MinReplicas:    autoscaling.MinReplicas,
+			MaxReplicas:    maxReplicas,
+			Metrics:        metrics,
+		},
+	}
+}
+
+func utilizationMetric(name string, utilization *int) k8s.MetricSpec {
+	return k8s.MetricSpec{
+		Type: "Resource",
+		Resource: &k8s.ResourceMetricSource{
+			Name:   name,
+			Target: k8s.MetricTarget{Type: "Utilization", AverageUtilization: utilization},
+		},
+	}
+}
+
+func buildScaledObject(deployable *model.Deployable) k8s.ScaledObject {
+	autoscaling := deployable.Autoscaling
+	triggers := make([]k8s.ScaleTrigger, 0)
+	if autoscaling.CpuUtilization != nil {
+		triggers = append(triggers, utilizationTrigger("cpu", *autoscaling.CpuUtilization))
+	}
+	if autoscaling.MemoryUtilization != nil {
+		triggers = append(triggers, utilizationTrigger("memory", *autoscaling.MemoryUtilization))
+	}
+	for _, trigger := range autoscaling.Triggers {
+		triggers = append(triggers, k8s.ScaleTrigger{
+			Type:     trigger.Type,
+			Metadata: trigger.Metadata,
+		})
+	}
+	return k8s.ScaledObject{
+		TypeMeta: k8s.TypeMeta{ApiVersion: "keda.sh/v1alpha1", Kind: "ScaledObject"},
+		Metadata: objectMeta(deployable, false),
+		Spec: k8s.ScaledObjectSpec{
+			ScaleTargetRef:  deploymentReference(deployable),
+			MinReplicaCount: autoscaling.MinReplicas,
+			MaxReplicaCount: autoscaling.MaxReplicas,
+			Triggers:        triggers,
+		},
+	}
+}
+
+func utilizationTrigger(name string, utilization int) k8s.ScaleTrigger {
+	return k8s.ScaleTrigger{
+		Type:       name,
+		MetricType: "Utilization",
+		Metadata:   map[string]string{"value": strconv.Itoa(utilization)},
+	}
+}
+
+func buildPodDisruptionBudget(deployable *model.Deployable) k8s.PodDisruptionBudget {
+	return k8s.PodDisruptionBudget{
+		TypeMeta: k8s.TypeMeta{ApiVersion: "policy/v1", Kind: "PodDisruptionBudget"},
+		Metadata: objectMeta(deployable, false),
+		Spec: k8s.PodDisruptionBudgetSpec{
+			MinAvailable:   k8s.NewIntOrString(deployable.DisruptionBudget.MinAvailable),
+			MaxUnavailable: k8s.NewIntOrString(deployable.DisruptionBudget.MaxUnavailable),
+			Selector:       k8s.LabelSelector{MatchLabels: deployable.Metadata.SelectorLabels},
+		},
+	}
+}
+
+func deploymentReference(deployable *model.Deployable) k8s.CrossVersionObjectReference {
+	return k8s.CrossVersionObjectReference{
+		ApiVersion: "apps/v1",
+		Kind:       "Deployment",
+		Name:       objectName(deployable),
+	}
+}
+
+// buildEnv sorts the variables by name so the output is stable between runs
+func buildEnv(env map[string]string) []k8s.EnvVar {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	vars := make([]k8s.EnvVar, 0, len(names))
+	for _, name := range names {
+		vars = append(vars, k8s.EnvVar{Name: strings.ToUpper(name), Value: env[name]})
+	}
+	return vars
+}
+
+func resourceValues(value *model.ResourceValue) map[string]string {
+	if value == nil {
+		return nil
+	}
+	values := make(map[string]string, 0)
+	if value.Cpu != nil {
+		values["cpu"] = *value.Cpu
+	}
+	if value.Memory != nil {
+		values["memory"] = *value.Memory
+	}
+	return values
+}
+
+func stringValues(values []*string) []string {
+	items := make([]string, 0, len(values))
+	for _, value := range values {
+		if value != nil {
+			items = append(items, *value)
+		}
+	}
+	return items
+}
+
+func parseRunAsUser(value string) (*int64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	uid, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("runAsUser [%s] is not a numeric user id", value))
+	}
+	return &uid, nil
+}
@@ -0,0 +1,130 @@
+package templates
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/skhatri/shores/pkg/chartrepo"
+	"github.com/skhatri/shores/pkg/functions"
+	"github.com/skhatri/shores/pkg/k8s"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	VersioningSemver      = "semver"
+	VersioningRelease     = "release"
+	VersioningContentHash = "content-hash"
+
+	contentHashAnnotation = "shores.io/content-hash"
+)
+
+var numericPrefix = regexp.MustCompile(`^v?([0-9]+)(?:\.([0-9]+))?(?:\.([0-9]+))?`)
+var prereleaseInvalid = regexp.MustCompile(`[^0-9A-Za-z-]+`)
+
+type semver struct {
+	major, minor, patch int
+	prerelease          string
+}
+
+func (v semver) String() string {
+	version := fmt.Sprintf("%d.%d.%d", v.major, v.minor, v.patch)
+	if v.prerelease != "" {
+		version = fmt.Sprintf("%s-%s", version, v.prerelease)
+	}
+	return version
+}
+
+func (v semver) less(other semver) bool {
+	if v.major != other.major {
+		return v.major < other.major
+	}
+	if v.minor != other.minor {
+		return v.minor < other.minor
+	}
+	return v.patch < other.patch
+}
+
+// SemverFromTag coerces an image tag into a chart version, 0.1 becomes 0.1.0 and a tag without numbers such as latest becomes 0.0.0-latest
+func SemverFromTag(tag string) string {
+	return parseSemver(tag).String()
+}
+
+func parseSemver(tag string) semver {
+	match := numericPrefix.FindStringSubmatch(tag)
+	if match == nil {
+		return semver{prerelease: prereleaseIdentifier(tag)}
+	}
+	version := semver{}
+	version.major, _ = strconv.Atoi(match[1])
+	version.minor, _ = strconv.Atoi(match[2])
+	version.patch, _ = strconv.Atoi(match[3])
+	rest := strings.TrimLeft(tag[len(match[0]):], "-.+")
+	version.prerelease = prereleaseIdentifier(rest)
+	return version
+}
+
+func prereleaseIdentifier(value string) string {
+	return strings.Trim(prereleaseInvalid.ReplaceAllString(value, "-"), "-")
+}
+
+// ChartVersion assigns the chart version according to the versioning scheme, previous is the Chart.yaml of the last run if any
+func ChartVersion(scheme string, appVersion string, releaseId string, hash string, previous *k8s.Chart) (string, error) {
+	tagVersion := parseSemver(appVersion)
+	switch scheme {
+	case "", VersioningSemver:
+		return tagVersion.String(), nil
+	case VersioningRelease:
+		tagVersion.prerelease = prereleaseIdentifier(releaseId)
+		return tagVersion.String(), nil
+	case VersioningContentHash:
+		if previous == nil {
+			return tagVersion.String(), nil
+		}
+		if previous.Annotations[contentHashAnnotation] == hash {
+			return previous.Version, nil
+		}
+		bumped := parseSemver(previous.Version)
+		bumped.prerelease = ""
+		bumped.patch++
+		if bumped.less(tagVersion) {
+			return tagVersion.String(), nil
+		}
+		return bumped.String(), nil
+	}
+	return "", errors.New(fmt.Sprintf("unknown chart versioning [%s], expected %s, %s or %s", scheme, VersioningSemver, VersioningRelease, VersioningContentHash))
+}
+
+// readChart returns the Chart.yaml written by a previous run, nil when there is none
+func readChart(file string) *k8s.Chart {
+	chart := k8s.Chart{}
+	if err := functions.UnmarshalFile(file, &chart); err != nil {
+		return nil
+	}
+	return &chart
+}
+
+// deploymentInfoAnnotation records the run that generated a manifest, it differs on every run
+const deploymentInfoAnnotation = "app.kubernetes.io/deployment-info"
+
+// contentHash digests the chart files, the deployment-info annotation changes on every run and is left out
+func contentHash(files []chartrepo.File) string {
+	sorted := append([]chartrepo.File{}, files...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Path < sorted[j].Path
+	})
+	digest := sha256.New()
+	for _, file := range sorted {
+		digest.Write([]byte(file.Path))
+		for _, line := range strings.Split(string(file.Content), "\n") {
+			if strings.Contains(line, deploymentInfoAnnotation) {
+				continue
+			}
+			digest.Write([]byte(line))
+		}
+	}
+	return hex.EncodeToString(digest.Sum(nil))
+}
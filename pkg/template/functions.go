@@ -0,0 +1,180 @@
+package templates
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/skhatri/shores/pkg/datamap"
+	"gopkg.in/yaml.v2"
+	"reflect"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+const dns1123MaxLength = 63
+
+var dns1123Invalid = regexp.MustCompile("[^a-z0-9-]+")
+var dns1123Dashes = regexp.MustCompile("-+")
+
+// TemplateFuncs is the function library available to built-in and provider templates
+func TemplateFuncs(dataMaps map[string]map[string]string, globals map[string]string) template.FuncMap {
+	return template.FuncMap{
+		"ToUpper":   strings.ToUpper,
+		"ToLower":   strings.ToLower,
+		"indent":    indent,
+		"nindent":   nindent,
+		"toYaml":    toYaml,
+		"toJson":    toJson,
+		"quote":     quote,
+		"squote":    squote,
+		"default":   defaultValue,
+		"required":  required,
+		"b64enc":    b64enc,
+		"sha256sum": sha256sum,
+		"trunc":     trunc,
+		"replace":   replace,
+		"dict":      dict,
+		"list":      list,
+		"dnsName":   DNSName,
+		"datamap": func(mapName string, key string) (string, error) {
+			return datamap.Lookup(dataMaps, mapName, key)
+		},
+		"global": func(name string) (string, error) {
+			value, ok := globals[name]
+			if !ok {
+				return "", errors.New(fmt.Sprintf("global [%s] is not defined", name))
+			}
+			return value, nil
+		},
+	}
+}
+
+// indent pads every line of s with n spaces
+func indent(n int, s string) string {
+	pad := strings.Repeat(" ", n)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+func nindent(n int, s string) string {
+	return "\n" + indent(n, s)
+}
+
+func toYaml(v interface{}) (string, error) {
+	content, err := yaml.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(content), "\n"), nil
+}
+
+func toJson(v interface{}) (string, error) {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// quote renders a double quoted scalar, the escapes used are valid in both json and yaml
+func quote(v interface{}) string {
+	content, _ := json.Marshal(fmt.Sprint(v))
+	return string(content)
+}
+
+// squote renders a single quoted yaml scalar, embedded quotes are doubled
+func squote(v interface{}) string {
+	return "'" + strings.ReplaceAll(fmt.Sprint(v), "'", "''") + "'"
+}
+
+// defaultValue returns given unless it is empty, it takes the default first so it reads well in a pipeline
+func defaultValue(fallback interface{}, given ...interface{}) interface{} {
+	if len(given) == 0 || isEmpty(given[0]) {
+		return fallback
+	}
+	return given[0]
+}
+
+func required(message string, v interface{}) (interface{}, error) {
+	if isEmpty(v) {
+		return nil, errors.New(message)
+	}
+	return v, nil
+}
+
+func isEmpty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	value := reflect.ValueOf(v)
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if value.IsNil() {
+			return true
+		}
+		return isEmpty(value.Elem().Interface())
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return value.Len() == 0
+	}
+	return value.IsZero()
+}
+
+func b64enc(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+func sha256sum(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// trunc keeps the first n characters, a negative n keeps the last characters instead
+func trunc(n int, s string) string {
+	runes := []rune(s)
+	if n >= 0 {
+		if len(runes) > n {
+			return string(runes[:n])
+		}
+		return s
+	}
+	if len(runes) > -n {
+		return string(runes[len(runes)+n:])
+	}
+	return s
+}
+
+func replace(old string, new string, s string) string {
+	return strings.ReplaceAll(s, old, new)
+}
+
+func dict(pairs ...interface{}) (map[string]interface{}, error) {
+	if len(pairs)%2 != 0 {
+		return nil, errors.New(fmt.Sprintf("dict expects key value pairs, got %d arguments", len(pairs)))
+	}
+	values := make(map[string]interface{}, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("dict key %v is not a string", pairs[i]))
+		}
+		values[key] = pairs[i+1]
+	}
+	return values, nil
+}
+
+func list(items ...interface{}) []interface{} {
+	return items
+}
+
+// DNSName turns s into a DNS-1123 label: lower case alphanumerics and dashes, at most 63 characters
+func DNSName(s string) string {
+	name := dns1123Invalid.ReplaceAllString(strings.ToLower(s), "-")
+	name = dns1123Dashes.ReplaceAllString(name, "-")
+	if len(name) > dns1123MaxLength {
+		name = name[:dns1123MaxLength]
+	}
+	return strings.Trim(name, "-")
+}
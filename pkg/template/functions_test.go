@@ -0,0 +1,279 @@
+package templates
+
+import (
+	"github.com/skhatri/shores/pkg/customtemplate"
+	"github.com/skhatri/shores/pkg/model"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestIndent(t *testing.T) {
+	tests := []struct {
+		name    string
+		fn      func(int, string) string
+		n       int
+		input   string
+		expects string
+	}{
+		{"indent one line", indent, 2, "a: 1", "  a: 1"},
+		{"indent every line", indent, 4, "a:\n  b: 1\nc: 2", "    a:\n      b: 1\n    c: 2"},
+		{"indent an empty string", indent, 2, "", "  "},
+		{"indent by zero", indent, 0, "a\nb", "a\nb"},
+		{"nindent starts a new line", nindent, 2, "a: 1", "\n  a: 1"},
+		{"nindent every line", nindent, 2, "a: 1\nb: 2", "\n  a: 1\n  b: 2"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.fn(test.n, test.input); got != test.expects {
+				t.Errorf("got %q, want %q", got, test.expects)
+			}
+		})
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		name    string
+		fn      func(interface{}) string
+		input   interface{}
+		expects string
+	}{
+		{"quote plain", quote, "nginx", `"nginx"`},
+		{"quote escapes double quotes", quote, `say "hi"`, `"say \"hi\""`},
+		{"quote escapes backslashes", quote, `c:\tmp`, `"c:\\tmp"`},
+		{"quote escapes new lines", quote, "a\nb", `"a\nb"`},
+		{"quote keeps single quotes", quote, "it's", `"it's"`},
+		{"quote a number", quote, 8080, `"8080"`},
+		{"quote a bool", quote, true, `"true"`},
+		{"squote plain", squote, "nginx", `'nginx'`},
+		{"squote doubles single quotes", squote, "it's", `'it''s'`},
+		{"squote keeps double quotes and backslashes", squote, `say "hi" \n`, `'say "hi" \n'`},
+		{"squote a number", squote, 3, `'3'`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.fn(test.input); got != test.expects {
+				t.Errorf("got %s, want %s", got, test.expects)
+			}
+		})
+	}
+}
+
+func TestDefaultAndRequired(t *testing.T) {
+	empty := ""
+	value := "v"
+	zero := 0
+	var nilMap map[string]string
+	tests := []struct {
+		name  string
+		given interface{}
+		empty bool
+	}{
+		{"nil", nil, true},
+		{"empty string", "", true},
+		{"string", "v", false},
+		{"zero int", 0, true},
+		{"int", 3, false},
+		{"false", false, true},
+		{"true", true, false},
+		{"nil pointer", (*string)(nil), true},
+		{"pointer to an empty string", &empty, true},
+		{"pointer to a string", &value, false},
+		{"pointer to zero", &zero, true},
+		{"empty slice", []string{}, true},
+		{"slice", []string{"a"}, false},
+		{"nil map", nilMap, true},
+		{"empty map", map[string]string{}, true},
+		{"map", map[string]string{"a": "b"}, false},
+		{"zero struct", model.ResourceValue{}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := defaultValue("fallback", test.given)
+			if test.empty && got != "fallback" {
+				t.Errorf("default gave %v, want the fallback", got)
+			}
+			if !test.empty && !reflect.DeepEqual(got, test.given) {
+				t.Errorf("default gave %v, want %v", got, test.given)
+			}
+			_, err := required("value is required", test.given)
+			if test.empty && (err == nil || err.Error() != "value is required") {
+				t.Errorf("required gave error %v, want the message", err)
+			}
+			if !test.empty && err != nil {
+				t.Errorf("required gave error %v", err)
+			}
+		})
+	}
+	if got := defaultValue("fallback"); got != "fallback" {
+		t.Errorf("default without a value gave %v", got)
+	}
+}
+
+func TestTrunc(t *testing.T) {
+	tests := []struct {
+		n       int
+		input   string
+		expects string
+	}{
+		{3, "abcdef", "abc"},
+		{6, "abcdef", "abcdef"},
+		{10, "abc", "abc"},
+		{0, "abc", ""},
+		{-3, "abcdef", "def"},
+		{-6, "abcdef", "abcdef"},
+		{-10, "abc", "abc"},
+		{2, "", ""},
+		{-2, "", ""},
+		{2, "héllo", "hé"},
+		{-3, "héllo", "llo"},
+		{-4, "héllo", "éllo"},
+		{3, "日本語です", "日本語"},
+		{-2, "日本語です", "です"},
+		{5, "日本語", "日本語"},
+	}
+	for _, test := range tests {
+		got := trunc(test.n, test.input)
+		if got != test.expects {
+			t.Errorf("trunc(%d, %q) = %q, want %q", test.n, test.input, got, test.expects)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("trunc(%d, %q) = %q is not valid utf-8", test.n, test.input, got)
+		}
+	}
+}
+
+func TestDict(t *testing.T) {
+	tests := []struct {
+		name    string
+		pairs   []interface{}
+		expects map[string]interface{}
+		err     string
+	}{
+		{"no pairs", nil, map[string]interface{}{}, ""},
+		{"pairs", []interface{}{"a", 1, "b", "two"}, map[string]interface{}{"a": 1, "b": "two"}, ""},
+		{"a later key wins", []interface{}{"a", 1, "a", 2}, map[string]interface{}{"a": 2}, ""},
+		{"odd count", []interface{}{"a", 1, "b"}, nil, "dict expects key value pairs, got 3 arguments"},
+		{"single argument", []interface{}{"a"}, nil, "dict expects key value pairs, got 1 arguments"},
+		{"key that is not a string", []interface{}{1, "a"}, nil, "dict key 1 is not a string"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := dict(test.pairs...)
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Fatalf("error %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.expects) {
+				t.Errorf("got %v, want %v", got, test.expects)
+			}
+		})
+	}
+}
+
+func TestDNSName(t *testing.T) {
+	tests := []struct {
+		input   string
+		expects string
+	}{
+		{"nginx", "nginx"},
+		{"My_App.Service", "my-app-service"},
+		{"a  b__c", "a-b-c"},
+		{"--todo--", "todo"},
+		{"_todo.", "todo"},
+		{"Ünïcode", "n-code"},
+		{strings.Repeat("a", 63), strings.Repeat("a", 63)},
+		{strings.Repeat("a", 70), strings.Repeat("a", 63)},
+		{strings.Repeat("a", 62) + "-bcd", strings.Repeat("a", 62)},
+		{strings.Repeat("a", 62) + "..bcd", strings.Repeat("a", 62)},
+		{"---", ""},
+	}
+	for _, test := range tests {
+		got := DNSName(test.input)
+		if got != test.expects {
+			t.Errorf("DNSName(%q) = %q, want %q", test.input, got, test.expects)
+		}
+		if len(got) > dns1123MaxLength {
+			t.Errorf("DNSName(%q) has %d characters", test.input, len(got))
+		}
+	}
+}
+
+func renderCustomText(t *testing.T, text string, dataMaps map[string]map[string]string, globals map[string]string) (string, error) {
+	t.Helper()
+	custom := customtemplate.TemplateSpec{File: "custom.yaml", Text: text}
+	content, err := RenderCustom(custom, TemplateData{Deployable: &model.Deployable{}, Globals: globals}, dataMaps)
+	return string(content), err
+}
+
+func TestLookups(t *testing.T) {
+	dataMaps := map[string]map[string]string{
+		"cpu":    {"c1": "100m", "default": "250m"},
+		"memory": {"m1": "128Mi"},
+	}
+	globals := map[string]string{"REGION": "ap-east-1"}
+	tests := []struct {
+		name    string
+		text    string
+		expects string
+		err     string
+	}{
+		{"datamap hit", `{{ datamap "cpu" "c1" }}`, "100m", ""},
+		{"datamap default", `{{ datamap "cpu" "c9" }}`, "250m", ""},
+		{"datamap key miss", `{{ datamap "memory" "m9" }}`, "", "data map [memory] has no value for [m9] and no default"},
+		{"datamap map miss", `{{ datamap "disk" "d1" }}`, "", "data map [disk] not found"},
+		{"global hit", `{{ global "REGION" }}`, "ap-east-1", ""},
+		{"global miss", `{{ global "ZONE" }}`, "", "global [ZONE] is not defined"},
+		{"missing globals key fails before default", `{{ .Globals.ZONE | default "none" }}`, "", "map has no entry for key"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := renderCustomText(t, test.text, dataMaps, globals)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("error %v, want it to mention %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.expects {
+				t.Errorf("got %q, want %q", got, test.expects)
+			}
+		})
+	}
+}
+
+func TestErrorsCarryTemplateNameAndLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		location string
+		message  string
+	}{
+		{"required", "a: 1\nb: {{ required \"b is required\" \"\" }}\n", "custom.yaml:2:", "b is required"},
+		{"global", "a: 1\nb: 2\nc: {{ global \"MISSING\" }}\n", "custom.yaml:3:", "global [MISSING] is not defined"},
+		{"dict", "{{ toYaml (dict \"a\") }}\n", "custom.yaml:1:", "dict expects key value pairs"},
+		{"unknown function", "a: 1\nb: {{ nosuch \"x\" }}\n", "custom.yaml:2:", `function "nosuch" not defined`},
+		{"unclosed action", "a: 1\nb: 2\nc: 3\nd: {{ quote \"x\"\n", "started at custom.yaml:4", "unclosed action"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := renderCustomText(t, test.text, nil, nil)
+			if err == nil {
+				t.Fatal("template rendered without an error")
+			}
+			if !strings.Contains(err.Error(), test.location) || !strings.Contains(err.Error(), test.message) {
+				t.Errorf("error %q, want it to mention %q and %q", err, test.location, test.message)
+			}
+		})
+	}
+}
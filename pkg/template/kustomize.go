@@ -0,0 +1,358 @@
+package templates
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/skhatri/shores/pkg/applog"
+	"github.com/skhatri/shores/pkg/chartrepo"
+	"github.com/skhatri/shores/pkg/customtemplate"
+	"github.com/skhatri/shores/pkg/environment"
+	"github.com/skhatri/shores/pkg/functions"
+	"github.com/skhatri/shores/pkg/k8s"
+	"github.com/skhatri/shores/pkg/model"
+	"gopkg.in/yaml.v2"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const kustomizeApiVersion = "kustomize.config.k8s.io/v1beta1"
+
+// runKustomize writes a base per app rendered for the context and an overlay per environment of the product set,
+// overlays patch every difference of the rendered objects and add or delete the objects only one side has
+func runKustomize(productSet *model.ProductSet, task model.Task, ctx environment.Context) (*model.DeploymentSummary, error) {
+	if task.Output == model.StdoutOutput {
+		return nil, errors.New(fmt.Sprintf("the %s mode writes a directory layout and cannot stream to stdout", ModeKustomize))
+	}
+	if task.ChartRepo != "" {
+		return nil, errors.New(fmt.Sprintf("the %s mode produces no charts to package", ModeKustomize))
+	}
+	lookup := loadLookupData(ctx)
+	customTemplates := customtemplate.LoadTemplates(functions.ListFiles("spec/provider/templates", ".yaml"))
+	bases := make(map[string][]chartrepo.File)
+	items := make([]model.DeploymentItem, 0)
+	for _, app := range productSet.Apps {
+		applog.Tag("kustomize").WithAttribute("app_name", app.Name).Info("Generating base")
+		deployable, err := prepareApp(app, lookup, task)
+		if err != nil {
+			return nil, err
+		}
+		files, kind, err := renderApp(deployable, lookup, task, customTemplates)
+		if err != nil {
+			return nil, err
+		}
+		resources := make([]string, 0)
+		for i, file := range files {
+			files[i].Path = filepath.Base(file.Path)
+			resources = append(resources, files[i].Path)
+		}
+		appDir := fmt.Sprintf("%s/%s/", task.Output, app.Name)
+		kerr := writeKustomization(appDir+"base/", files, k8s.Kustomization{Resources: resources})
+		if kerr != nil {
+			return nil, errors.New(fmt.Sprintf("task: kustomize, app: [%s], error: [%v]", app.Name, kerr))
+		}
+		bases[app.Name] = files
+		items = append(items, model.DeploymentItem{
+			Name: app.Name,
+			Kind: kind,
+			Path: appDir,
+			Qos:  deployable.QosClass,
+		})
+	}
+
+	catalog := loadCatalog()
+	for _, overlay := range productSet.Overlays {
+		if overlay.Name == "" {
+			return nil, errors.New("task: kustomize, error: [overlay needs an env, location or cluster]")
+		}
+		overlayContext, cerr := resolveContext(overlay, catalog)
+		if cerr != nil {
+			return nil, errors.New(fmt.Sprintf("task: kustomize, overlay: [%s], error: [%v]", overlay.Name, cerr))
+		}
+		overlayLookup := loadLookupData(overlayContext)
+		for _, app := range productSet.Apps {
+			applog.Tag("kustomize").WithAttribute("app_name", app.Name).WithAttribute("overlay", overlay.Name).Info("Generating overlay")
+			deployable, err := prepareApp(app, overlayLookup, task)
+			if err != nil {
+				return nil, err
+			}
+			rendered, _, err := renderApp(deployable, overlayLookup, task, customTemplates)
+			if err != nil {
+				return nil, err
+			}
+			for i, file := range rendered {
+				rendered[i].Path = filepath.Base(file.Path)
+			}
+			files, kustomization, err := overlayFiles(bases[app.Name], rendered)
+			if err != nil {
+				return nil, errors.New(fmt.Sprintf("task: kustomize, app: [%s], overlay: [%s], error: [%v]", app.Name, overlay.Name, err))
+			}
+			overlayDir := fmt.Sprintf("%s/%s/overlays/%s/", task.Output, app.Name, overlay.Name)
+			kerr := writeKustomization(overlayDir, files, kustomization)
+			if kerr != nil {
+				return nil, errors.New(fmt.Sprintf("task: kustomize, app: [%s], overlay: [%s], error: [%v]", app.Name, overlay.Name, kerr))
+			}
+		}
+	}
+	return &model.DeploymentSummary{
+		Namespace: *productSet.Namespace,
+		Items:     items,
+	}, nil
+}
+
+// overlayFiles compares the rendered files of the base and the overlay, objects only the overlay renders are added,
+// objects it no longer renders are deleted and any other difference becomes a json6902 patch of the base object
+func overlayFiles(baseFiles []chartrepo.File, files []chartrepo.File) ([]chartrepo.File, k8s.Kustomization, error) {
+	kustomization := k8s.Kustomization{Resources: []string{"../../base"}}
+	result := make([]chartrepo.File, 0)
+	baseContent := make(map[string][]byte)
+	for _, file := range baseFiles {
+		baseContent[file.Path] = file.Content
+	}
+	inOverlay := make(map[string]bool)
+	for _, file := range files {
+		inOverlay[file.Path] = true
+		content, ok := baseContent[file.Path]
+		if !ok {
+			result = append(result, file)
+			kustomization.Resources = append(kustomization.Resources, file.Path)
+			continue
+		}
+		baseObjects, err := decodeObjects(content)
+		if err != nil {
+			return nil, kustomization, errors.New(fmt.Sprintf("file: [%s], error: [%v]", file.Path, err))
+		}
+		overlayObjects, err := decodeObjects(file.Content)
+		if err != nil {
+			return nil, kustomization, errors.New(fmt.Sprintf("file: [%s], error: [%v]", file.Path, err))
+		}
+		if len(baseObjects) != len(overlayObjects) {
+			return nil, kustomization, errors.New(fmt.Sprintf("file: [%s], error: [the base has %d objects and the overlay %d, which a patch cannot express]",
+				file.Path, len(baseObjects), len(overlayObjects)))
+		}
+		for i := range baseObjects {
+			target, err := patchTarget(baseObjects[i], overlayObjects[i])
+			if err != nil {
+				return nil, kustomization, errors.New(fmt.Sprintf("file: [%s], error: [%v]", file.Path, err))
+			}
+			ops := jsonPatch("", baseObjects[i], overlayObjects[i])
+			if len(ops) == 0 {
+				continue
+			}
+			patch, err := yaml.Marshal(ops)
+			if err != nil {
+				return nil, kustomization, err
+			}
+			patchFile := patchFileName(file.Path, i, len(baseObjects))
+			result = append(result, chartrepo.File{Path: patchFile, Content: patch})
+			kustomization.Patches = append(kustomization.Patches, k8s.KustomizationPatch{Path: patchFile, Target: target})
+		}
+	}
+	for _, file := range baseFiles {
+		if inOverlay[file.Path] {
+			continue
+		}
+		objects, err := decodeObjects(file.Content)
+		if err != nil {
+			return nil, kustomization, errors.New(fmt.Sprintf("file: [%s], error: [%v]", file.Path, err))
+		}
+		for i, object := range objects {
+			patch, err := yaml.Marshal(deletePatch(object))
+			if err != nil {
+				return nil, kustomization, err
+			}
+			patchFile := patchFileName(file.Path, i, len(objects))
+			result = append(result, chartrepo.File{Path: patchFile, Content: patch})
+			kustomization.Patches = append(kustomization.Patches, k8s.KustomizationPatch{Path: patchFile})
+		}
+	}
+	return result, kustomization, nil
+}
+
+// decodeObjects reads every document of a rendered file, empty documents are skipped
+func decodeObjects(content []byte) ([]map[string]interface{}, error) {
+	objects := make([]map[string]interface{}, 0)
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var document interface{}
+		err := decoder.Decode(&document)
+		if err == io.EOF {
+			return objects, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if document == nil {
+			continue
+		}
+		object, ok := normalise(document).(map[string]interface{})
+		if !ok {
+			return nil, errors.New("a document is not a kubernetes object")
+		}
+		objects = append(objects, object)
+	}
+}
+
+// normalise turns the maps yaml decodes into string keyed maps so objects compare and sort by key
+func normalise(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[fmt.Sprint(key)] = normalise(item)
+		}
+		return m
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = normalise(item)
+		}
+		return list
+	}
+	return value
+}
+
+func stringAt(object map[string]interface{}, keys ...string) string {
+	var value interface{} = object
+	for _, key := range keys {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		value = m[key]
+	}
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// patchTarget selects the base object, the overlay has to keep its api version and kind
+func patchTarget(base map[string]interface{}, overlay map[string]interface{}) (*k8s.KustomizationTarget, error) {
+	apiVersion, kind := stringAt(base, "apiVersion"), stringAt(base, "kind")
+	if apiVersion != stringAt(overlay, "apiVersion") || kind != stringAt(overlay, "kind") {
+		return nil, errors.New(fmt.Sprintf("the base renders %s %s and the overlay %s %s, which a patch cannot express",
+			apiVersion, kind, stringAt(overlay, "apiVersion"), stringAt(overlay, "kind")))
+	}
+	target := &k8s.KustomizationTarget{
+		Version:   apiVersion,
+		Kind:      kind,
+		Name:      stringAt(base, "metadata", "name"),
+		Namespace: stringAt(base, "metadata", "namespace"),
+	}
+	if i := strings.Index(apiVersion, "/"); i >= 0 {
+		target.Group, target.Version = apiVersion[:i], apiVersion[i+1:]
+	}
+	return target, nil
+}
+
+var pointerEscape = strings.NewReplacer("~", "~0", "/", "~1")
+
+func patchOp(op string, pointer string, value interface{}, withValue bool) yaml.MapSlice {
+	patch := yaml.MapSlice{{Key: "op", Value: op}, {Key: "path", Value: pointer}}
+	if withValue {
+		patch = append(patch, yaml.MapItem{Key: "value", Value: value})
+	}
+	return patch
+}
+
+// jsonPatch lists the json6902 operations turning the base value into the overlay value,
+// lists are compared by position with the items only one side has removed or appended
+func jsonPatch(pointer string, base interface{}, overlay interface{}) []yaml.MapSlice {
+	switch b := base.(type) {
+	case map[string]interface{}:
+		if o, ok := overlay.(map[string]interface{}); ok {
+			keys := make([]string, 0)
+			for key := range b {
+				keys = append(keys, key)
+			}
+			for key := range o {
+				if _, exists := b[key]; !exists {
+					keys = append(keys, key)
+				}
+			}
+			sort.Strings(keys)
+			ops := make([]yaml.MapSlice, 0)
+			for _, key := range keys {
+				child := pointer + "/" + pointerEscape.Replace(key)
+				baseValue, inBase := b[key]
+				overlayValue, inOverlay := o[key]
+				switch {
+				case !inOverlay:
+					ops = append(ops, patchOp("remove", child, nil, false))
+				case !inBase:
+					ops = append(ops, patchOp("add", child, overlayValue, true))
+				default:
+					ops = append(ops, jsonPatch(child, baseValue, overlayValue)...)
+				}
+			}
+			return ops
+		}
+	case []interface{}:
+		if o, ok := overlay.([]interface{}); ok {
+			ops := make([]yaml.MapSlice, 0)
+			for i := 0; i < len(b) && i < len(o); i++ {
+				ops = append(ops, jsonPatch(fmt.Sprintf("%s/%d", pointer, i), b[i], o[i])...)
+			}
+			for i := len(b) - 1; i >= len(o); i-- {
+				ops = append(ops, patchOp("remove", fmt.Sprintf("%s/%d", pointer, i), nil, false))
+			}
+			for i := len(b); i < len(o); i++ {
+				ops = append(ops, patchOp("add", pointer+"/-", o[i], true))
+			}
+			return ops
+		}
+	}
+	if reflect.DeepEqual(base, overlay) {
+		return nil
+	}
+	return []yaml.MapSlice{patchOp("replace", pointer, overlay, true)}
+}
+
+// patchFileName names the patch of each object a file renders, a file with one object keeps its name
+func patchFileName(file string, index int, count int) string {
+	if count == 1 {
+		return file
+	}
+	ext := filepath.Ext(file)
+	return fmt.Sprintf("%s-%d%s", strings.TrimSuffix(file, ext), index, ext)
+}
+
+// deletePatch removes an object of the base the overlay environment does not have
+func deletePatch(object map[string]interface{}) yaml.MapSlice {
+	metadata := yaml.MapSlice{{Key: "name", Value: stringAt(object, "metadata", "name")}}
+	if namespace := stringAt(object, "metadata", "namespace"); namespace != "" {
+		metadata = append(metadata, yaml.MapItem{Key: "namespace", Value: namespace})
+	}
+	return yaml.MapSlice{
+		{Key: "$patch", Value: "delete"},
+		{Key: "apiVersion", Value: stringAt(object, "apiVersion")},
+		{Key: "kind", Value: stringAt(object, "kind")},
+		{Key: "metadata", Value: metadata},
+	}
+}
+
+// writeKustomization writes the files into the directory along with its kustomization.yaml
+func writeKustomization(dir string, files []chartrepo.File, kustomization k8s.Kustomization) error {
+	cerr := createDirSafely(dir + "kustomization.yaml")
+	if cerr != nil {
+		return cerr
+	}
+	kustomization.TypeMeta = k8s.TypeMeta{ApiVersion: kustomizeApiVersion, Kind: "Kustomization"}
+	content, err := yaml.Marshal(kustomization)
+	if err != nil {
+		return err
+	}
+	files = append(files, chartrepo.File{Path: "kustomization.yaml", Content: content})
+	for _, file := range files {
+		werr := os.WriteFile(dir+file.Path, file.Content, 0644)
+		if werr != nil {
+			return werr
+		}
+	}
+	return nil
+}
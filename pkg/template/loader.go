@@ -4,14 +4,23 @@ import (
 	"errors"
 	"fmt"
 	"github.com/skhatri/shores/pkg/model"
-	"strings"
 	"text/template"
 )
 
-var ChartTemplate = `apiVersion: v1
-description: A Helm chart for Kubernetes {{ .Artifact.Name }}
+var ChartTemplate = `apiVersion: v2
 name: {{ .Artifact.Name }}
-version: {{ .Artifact.Version }}
+description: A Helm chart for Kubernetes {{ .Artifact.Name }}
+type: {{ .Chart.Type }}
+version: {{ .Chart.Version }}
+appVersion: {{ .Chart.AppVersion | quote }}{{ if .Chart.Keywords }}
+keywords:{{ range $keyword := .Chart.Keywords }}
+  - {{ $keyword | quote }}{{ end }}{{ end }}{{ if .Chart.Maintainers }}
+maintainers:{{ range $m := .Chart.Maintainers }}
+  - name: {{ $m.Name | quote }}{{ if $m.Email }}
+    email: {{ $m.Email | quote }}{{ end }}{{ if $m.Url }}
+    url: {{ $m.Url | quote }}{{ end }}{{ end }}{{ end }}{{ if .Chart.ContentHash }}
+annotations:
+  shores.io/content-hash: {{ .Chart.ContentHash }}{{ end }}
 
 `
 
@@ -67,7 +76,15 @@ metadata:
 {{ range $key, $value := .Metadata.Labels }}{{ $key |indent 4 }}: '{{ $value }}'
 {{ end }}{{ end }}
 spec:
-  replicas: {{ .Target.Replica }}
+  {{ if not .Autoscaling }}replicas: {{ .Target.Replica }}{{ end }}
+  {{ if .Rollout }}strategy:
+    type: {{ .Rollout.Strategy }}{{ if or .Rollout.MaxSurge .Rollout.MaxUnavailable }}
+    rollingUpdate:{{ if .Rollout.MaxSurge }}
+      maxSurge: {{ .Rollout.MaxSurge }}{{ end }}{{ if .Rollout.MaxUnavailable }}
+      maxUnavailable: {{ .Rollout.MaxUnavailable }}{{ end }}{{ end }}
+  {{ if .Rollout.MinReadySeconds }}minReadySeconds: {{ .Rollout.MinReadySeconds }}{{ end }}
+  {{ if .Rollout.RevisionHistoryLimit }}revisionHistoryLimit: {{ .Rollout.RevisionHistoryLimit }}{{ end }}
+  {{- end }}
   selector:
     {{ if .Metadata.SelectorLabels }}matchLabels:
 {{ range $key, $value := .Metadata.SelectorLabels }}{{ $key | indent 6 }}: {{ $value }}
@@ -79,7 +96,9 @@ spec:
 {{ end }}{{ end }}
     spec:
       serviceAccountName: {{ if .ServiceAccountName }}{{ .ServiceAccountName }}{{else}}{{ .Artifact.Name | ToLower }}{{end}}
-      containers:
+      {{ if .Rollout }}{{ if .Rollout.TerminationGracePeriodSeconds }}terminationGracePeriodSeconds: {{ .Rollout.TerminationGracePeriodSeconds }}
+      {{ end }}{{ if .Rollout.PriorityClassName }}priorityClassName: {{ .Rollout.PriorityClassName }}
+      {{ end }}{{ end }}containers:
         - name: {{ .Artifact.Name }}
           image: {{ .Artifact.Image }}
           imagePullPolicy: IfNotPresent
@@ -94,32 +113,31 @@ spec:
               containerPort: {{ $port.Port }}
               protocol: {{ $port.Protocol }}
 {{- end }}{{- end }}
-          {{ if .Checks -}}livenessProbe:
-            httpGet:
-              path: {{ .Checks.Path }}
-              port: {{ .Checks.Port }}
-              initialDelaySeconds: 30
-              timeoutSeconds: 100{{- end }}
-          {{ if .Checks -}}readinessProbe:
-            httpGet:
-              path: {{ .Checks.Path }}
-              port: {{ .Checks.Port }}
-              initialDelaySeconds: 30
-              timeoutSeconds: 100 {{- end }}
+          {{ if .Rollout }}{{ if .Rollout.PreStop }}lifecycle:
+            preStop:{{ if .Rollout.PreStop.SleepSeconds }}
+              sleep:
+                seconds: {{ .Rollout.PreStop.SleepSeconds }}{{ else }}
+              exec:
+                command:{{ range $cmd := .Rollout.PreStop.Exec }}
+                  - '{{ $cmd }}'{{ end }}{{ end }}{{ end }}{{ end }}
+          {{ if .Probes }}{{ if .Probes.Liveness }}livenessProbe:{{ template "probe" .Probes.Liveness }}
+          {{ end }}{{ if .Probes.Readiness }}readinessProbe:{{ template "probe" .Probes.Readiness }}
+          {{ end }}{{ if .Probes.Startup }}startupProbe:{{ template "probe" .Probes.Startup }}
+          {{ end }}{{ end }}
           {{if .Resources}}resources:
             {{ if .Resources.Requests }}requests:
               {{ if .Resources.Requests.Cpu }}cpu: "{{ .Resources.Requests.Cpu }}"{{end}}
               {{ if .Resources.Requests.Memory}}memory: "{{ .Resources.Requests.Memory }}"{{end}}
-		    {{- end }}
+            {{- end }}
             {{ if .Resources.Limits }}limits:
               {{ if .Resources.Limits.Cpu }}cpu: "{{ .Resources.Limits.Cpu }}"{{end}}
               {{ if .Resources.Limits.Memory}}memory: "{{ .Resources.Limits.Memory }}"{{end}}
             {{- end }}
-		  {{- end }}
+          {{- end }}
           {{ if .Env }}env:{{ range $key, $value := .Env }}
             - name: "{{ $key | ToUpper }}"
               value: "{{ $value }}"{{end}}
-		  {{- end }}
+          {{- end }}
           {{ if .Mounts }}volumeMounts:{{ range $mount := .Mounts }}
             - name: {{ $mount.Name }}
               mountPath: {{ $mount.Path }}
@@ -136,14 +154,143 @@ spec:
         {{ if .SecurityContext.RunAsUser }}runAsUser: {{ .SecurityContext.RunAsUser }}{{ end }}
       {{- end }}
       {{ if .Target.NodeSelector }}nodeSelector:
-{{ range $key, $value := .Target.NodeSelector }}{{ $key | indent 8 }}: {{ $value}}
+{{ range $key, $value := .Target.NodeSelector }}{{ $key | indent 8 }}: '{{ $value }}'
 {{end}}{{end}}
-      affinity: { }
-      tolerations: [ ]
+      {{ if .Target.Affinity }}affinity:
+        nodeAffinity:{{ if .Target.Affinity.Required }}
+          requiredDuringSchedulingIgnoredDuringExecution:
+            nodeSelectorTerms:
+              - matchExpressions:{{ range $term := .Target.Affinity.Required }}
+                  - key: {{ $term.Key }}
+                    operator: {{ $term.Operator }}{{ if $term.Values }}
+                    values:{{ range $v := $term.Values }}
+                      - '{{ $v }}'{{ end }}{{ end }}{{ end }}{{ end }}{{ if .Target.Affinity.Preferred }}
+          preferredDuringSchedulingIgnoredDuringExecution:{{ range $term := .Target.Affinity.Preferred }}
+            - weight: {{ $term.Weight }}
+              preference:
+                matchExpressions:
+                  - key: {{ $term.Key }}
+                    operator: {{ $term.Operator }}{{ if $term.Values }}
+                    values:{{ range $v := $term.Values }}
+                      - '{{ $v }}'{{ end }}{{ end }}{{ end }}{{ end }}
+      {{- else }}affinity: { }{{ end }}
+      {{ if .Target.Tolerations }}tolerations:{{ range $t := .Target.Tolerations }}
+        - {{ if $t.Key }}key: '{{ $t.Key }}'
+          {{ end }}operator: {{ if $t.Operator }}{{ $t.Operator }}{{ else }}Equal{{ end }}{{ if $t.Value }}
+          value: '{{ $t.Value }}'{{ end }}{{ if $t.Effect }}
+          effect: {{ $t.Effect }}{{ end }}{{ end }}
+      {{- else }}tolerations: [ ]{{ end }}
+      {{ if .Target.TopologySpread }}topologySpreadConstraints:{{ $selector := .Metadata.SelectorLabels }}{{ range $c := .Target.TopologySpread }}
+        - maxSkew: {{ $c.MaxSkew }}
+          topologyKey: {{ $c.TopologyKey }}
+          whenUnsatisfiable: {{ if $c.WhenUnsatisfiable }}{{ $c.WhenUnsatisfiable }}{{ else }}ScheduleAnyway{{ end }}
+          labelSelector:
+            matchLabels:{{ range $key, $value := $selector }}
+              {{ $key }}: {{ $value }}{{ end }}{{ end }}{{ end }}
       {{ if .Mounts }}volumes:{{ range $mount := .Mounts }}
         - name: {{ $mount.Name }}
           {{ if eq $mount.Type "emptyDir" }}emptyDir: { }{{end}}
 {{- end }}{{- end}}
+{{ define "probe" }}{{ if .HttpGet }}
+            httpGet:
+              path: '{{ .HttpGet.Path }}'
+              port: {{ .HttpGet.Port }}{{ if .HttpGet.Scheme }}
+              scheme: {{ .HttpGet.Scheme }}{{ end }}{{ end }}{{ if .TcpSocket }}
+            tcpSocket:
+              port: {{ .TcpSocket.Port }}{{ end }}{{ if .Exec }}
+            exec:
+              command:{{ range $cmd := .Exec.Command }}
+                - '{{ $cmd }}'{{ end }}{{ end }}{{ if .Grpc }}
+            grpc:
+              port: {{ .Grpc.Port }}{{ if .Grpc.Service }}
+              service: '{{ .Grpc.Service }}'{{ end }}{{ end }}
+            initialDelaySeconds: {{ .InitialDelaySeconds }}
+            periodSeconds: {{ .PeriodSeconds }}
+            timeoutSeconds: {{ .TimeoutSeconds }}
+            failureThreshold: {{ .FailureThreshold }}
+            successThreshold: {{ .SuccessThreshold }}{{ end }}`
+
+var HorizontalPodAutoscalerTemplate = `apiVersion: autoscaling/v2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: {{ .Artifact.Name | ToLower }}
+  namespace: {{ .Namespace }}
+  {{ if .Metadata.Labels }}labels:
+{{ range $key, $value := .Metadata.Labels }}{{ $key | indent 4 }}: '{{ $value }}'
+{{ end }}{{ end }}
+spec:
+  scaleTargetRef:
+    apiVersion: apps/v1
+    kind: Deployment
+    name: {{ .Artifact.Name | ToLower }}
+  minReplicas: {{ .Autoscaling.MinReplicas }}
+  maxReplicas: {{ .Autoscaling.MaxReplicas }}
+  metrics:{{ if .Autoscaling.CpuUtilization }}
+    - type: Resource
+      resource:
+        name: cpu
+        target:
+          type: Utilization
+          averageUtilization: {{ .Autoscaling.CpuUtilization }}{{ end }}{{ if .Autoscaling.MemoryUtilization }}
+    - type: Resource
+      resource:
+        name: memory
+        target:
+          type: Utilization
+          averageUtilization: {{ .Autoscaling.MemoryUtilization }}{{ end }}{{ range $metric := .Autoscaling.Metrics }}
+    - type: Pods
+      pods:
+        metric:
+          name: {{ $metric.Name }}
+        target:
+          type: AverageValue
+          averageValue: '{{ $metric.AverageValue }}'{{ end }}
+`
+
+var ScaledObjectTemplate = `apiVersion: keda.sh/v1alpha1
+kind: ScaledObject
+metadata:
+  name: {{ .Artifact.Name | ToLower }}
+  namespace: {{ .Namespace }}
+  {{ if .Metadata.Labels }}labels:
+{{ range $key, $value := .Metadata.Labels }}{{ $key | indent 4 }}: '{{ $value }}'
+{{ end }}{{ end }}
+spec:
+  scaleTargetRef:
+    apiVersion: apps/v1
+    kind: Deployment
+    name: {{ .Artifact.Name | ToLower }}
+  minReplicaCount: {{ .Autoscaling.MinReplicas }}
+  maxReplicaCount: {{ .Autoscaling.MaxReplicas }}
+  triggers:{{ if .Autoscaling.CpuUtilization }}
+    - type: cpu
+      metricType: Utilization
+      metadata:
+        value: '{{ .Autoscaling.CpuUtilization }}'{{ end }}{{ if .Autoscaling.MemoryUtilization }}
+    - type: memory
+      metricType: Utilization
+      metadata:
+        value: '{{ .Autoscaling.MemoryUtilization }}'{{ end }}{{ range $trigger := .Autoscaling.Triggers }}
+    - type: {{ $trigger.Type }}
+      metadata:{{ range $key, $value := $trigger.Metadata }}
+        {{ $key }}: '{{ $value }}'{{ end }}{{ end }}
+`
+
+var PodDisruptionBudgetTemplate = `apiVersion: policy/v1
+kind: PodDisruptionBudget
+metadata:
+  name: {{ .Artifact.Name | ToLower }}
+  namespace: {{ .Namespace }}
+  {{ if .Metadata.Labels }}labels:
+{{ range $key, $value := .Metadata.Labels }}{{ $key | indent 4 }}: '{{ $value }}'
+{{ end }}{{ end }}
+spec:
+  {{ if .DisruptionBudget.MinAvailable }}minAvailable: {{ .DisruptionBudget.MinAvailable }}{{ end }}
+  {{ if .DisruptionBudget.MaxUnavailable }}maxUnavailable: {{ .DisruptionBudget.MaxUnavailable }}{{ end }}
+  selector:
+    matchLabels:
+{{ range $key, $value := .Metadata.SelectorLabels }}{{ $key | indent 6 }}: {{ $value }}
+{{ end }}
 `
 
 var JobTemplate = `apiVersion: batch/v1
@@ -184,47 +331,35 @@ spec:
          {{ if .EnvVars }}env:{{ range $key, $value := .EnvVars }}
           - name: "{{ $key | ToUpper }}"
             value: "{{ $value }}"{{end}}
-		 {{ end }}
+          {{ end }}
       restartPolicy: {{ if .RestartPolicy -}}{{ .RestartPolicy }}{{ else }}Never{{end}} 
       affinity: {}
       nodeSelector: {}
       tolerations: []
 `
 
-//LoadTemplates parse static template to helm chart
-func LoadTemplates(tName string, deployable *model.Deployable) (*template.Template, error) {
-	appName := deployable.Artifact.Name
-	switch tName {
-	case "ChartTemplate":
-		return getTemplate("Chart.yaml", ChartTemplate)
-	case "DeploymentTemplate":
-		return getTemplate(fmt.Sprintf("%s-deployment.yaml", appName), DeploymentTemplate)
-	case "ServiceTemplate":
-		return getTemplate(fmt.Sprintf("%s-service.yaml", appName), ServiceTemplate)
-	case "ServiceAccountTemplate":
-		return getTemplate(fmt.Sprintf("%s-serviceaccount.yaml", appName), ServiceAccountTemplate)
-	case "JobTemplate":
-		return getTemplate(fmt.Sprintf("%s-job.yaml", appName), JobTemplate)
+// LoadTemplates parse the text template override of a helm chart file
+func LoadTemplates(tName string, deployable *model.Deployable, funcs template.FuncMap) (*template.Template, error) {
+	text := map[string]string{
+		"ChartTemplate":                   ChartTemplate,
+		"DeploymentTemplate":              DeploymentTemplate,
+		"ServiceTemplate":                 ServiceTemplate,
+		"ServiceAccountTemplate":          ServiceAccountTemplate,
+		"HorizontalPodAutoscalerTemplate": HorizontalPodAutoscalerTemplate,
+		"ScaledObjectTemplate":            ScaledObjectTemplate,
+		"PodDisruptionBudgetTemplate":     PodDisruptionBudgetTemplate,
+		"JobTemplate":                     JobTemplate,
+	}
+	if templateText, ok := text[tName]; ok {
+		return getTemplate(OutputFile(tName, deployable), templateText, funcs)
 	}
 	return nil, nil
 }
 
-func getTemplate(name string, templateType string) (*template.Template, error) {
-	indentFunc := func(suffix string) func(n int, s string) string {
-		return func(n int, s string) string {
-			return fmt.Sprintf("%s%s%s", strings.Repeat(" ", n), s, suffix)
-		}
-	}
-	funcMap := template.FuncMap{
-		"ToUpper": strings.ToUpper,
-		"ToLower": strings.ToLower,
-		"indent":  indentFunc(""),
-		"nindent": indentFunc("\n"),
-	}
-
-	tmpl, err := template.New(name).Funcs(funcMap).Parse(templateType)
+func getTemplate(name string, templateType string, funcs template.FuncMap) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(templateType)
 	if err != nil {
-		return nil, errors.New(fmt.Sprintf("error parsing %v ", err))
+		return nil, errors.New(fmt.Sprintf("error parsing %v", err))
 	}
 	return tmpl, nil
 }
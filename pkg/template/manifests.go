@@ -0,0 +1,33 @@
+package templates
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/skhatri/shores/pkg/chartrepo"
+	"github.com/skhatri/shores/pkg/model"
+	"os"
+)
+
+// writeManifests joins the app manifests into one multi-document stream, written to stdout or to <output>/<app>.yaml
+func writeManifests(output string, appName string, files []chartrepo.File) (string, error) {
+	stream := bytes.Buffer{}
+	for _, file := range files {
+		content := bytes.TrimSpace(file.Content)
+		if len(content) == 0 {
+			continue
+		}
+		stream.WriteString("---\n")
+		stream.Write(content)
+		stream.WriteString("\n")
+	}
+	if output == model.StdoutOutput {
+		_, err := os.Stdout.Write(stream.Bytes())
+		return output, err
+	}
+	manifestPath := fmt.Sprintf("%s/%s.yaml", output, appName)
+	cerr := createDirSafely(manifestPath)
+	if cerr != nil {
+		return "", cerr
+	}
+	return manifestPath, os.WriteFile(manifestPath, stream.Bytes(), 0644)
+}
@@ -0,0 +1,100 @@
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"github.com/skhatri/shores/pkg/chartrepo"
+	"github.com/skhatri/shores/pkg/functions"
+	"github.com/skhatri/shores/pkg/k8s"
+	"os"
+	"strings"
+)
+
+// chartRepository packages the generated charts into a local helm repository directory
+type chartRepository struct {
+	dir      string
+	created  string
+	index    *chartrepo.Index
+	archives map[string]chartrepo.File
+}
+
+func openChartRepository(dir string, created string) (*chartRepository, error) {
+	cerr := createDirSafely(dir + "/index.yaml")
+	if cerr != nil {
+		return nil, cerr
+	}
+	index, err := chartrepo.LoadIndex(dir + "/index.yaml")
+	if err != nil {
+		return nil, err
+	}
+	return &chartRepository{
+		dir:      dir,
+		created:  created,
+		index:    index,
+		archives: make(map[string]chartrepo.File, 0),
+	}, nil
+}
+
+// add archives the chart files, charts packaged earlier in the run are bundled for the dependencies listed in Chart.yaml
+func (repo *chartRepository) add(files []chartrepo.File) error {
+	chart, err := chartMetadata(files)
+	if err != nil {
+		return err
+	}
+	for _, dependency := range chart.Dependencies {
+		archive, ok := repo.archives[dependency.Name]
+		if !ok {
+			return errors.New(fmt.Sprintf("chart %s depends on %s which was not packaged", chart.Name, dependency.Name))
+		}
+		files = append(files, chartrepo.File{Path: "charts/" + archive.Path, Content: archive.Content})
+	}
+	packaged := make([]chartrepo.File, 0)
+	for _, file := range files {
+		packaged = append(packaged, chartrepo.File{Path: file.Path, Content: withoutRunInfo(file.Content)})
+	}
+	content, err := chartrepo.Archive(chart.Name, packaged)
+	if err != nil {
+		return err
+	}
+	archiveName := chartrepo.ArchiveName(chart.Name, chart.Version)
+	aerr := repo.index.Add(*chart, archiveName, chartrepo.Digest(content), repo.created)
+	if aerr != nil {
+		return aerr
+	}
+	werr := os.WriteFile(fmt.Sprintf("%s/%s", repo.dir, archiveName), content, 0644)
+	if werr != nil {
+		return werr
+	}
+	repo.archives[chart.Name] = chartrepo.File{Path: archiveName, Content: content}
+	return nil
+}
+
+// withoutRunInfo drops the deployment-info annotation so the same chart always packages into the same bytes
+func withoutRunInfo(content []byte) []byte {
+	lines := strings.Split(string(content), "\n")
+	kept := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if !strings.Contains(line, deploymentInfoAnnotation) {
+			kept = append(kept, line)
+		}
+	}
+	return []byte(strings.Join(kept, "\n"))
+}
+
+func (repo *chartRepository) writeIndex() error {
+	return repo.index.Write(repo.dir+"/index.yaml", repo.created)
+}
+
+func chartMetadata(files []chartrepo.File) (*k8s.Chart, error) {
+	for _, file := range files {
+		if file.Path != "Chart.yaml" {
+			continue
+		}
+		chart := k8s.Chart{}
+		if err := functions.UnmarshalYaml(file.Content, &chart); err != nil {
+			return nil, err
+		}
+		return &chart, nil
+	}
+	return nil, errors.New("chart has no Chart.yaml")
+}
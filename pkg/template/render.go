@@ -0,0 +1,138 @@
+package templates
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/skhatri/shores/pkg/customtemplate"
+	"github.com/skhatri/shores/pkg/environment"
+	"github.com/skhatri/shores/pkg/model"
+	"gopkg.in/yaml.v2"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+const (
+	RendererTyped = "typed"
+	RendererText  = "text"
+)
+
+func init() {
+	// long values such as the deployment-info annotation stay on one line
+	yaml.FutureLineWrap()
+}
+
+var templateFiles = map[string]string{
+	"DeploymentTemplate":              "%s-deployment.yaml",
+	"ServiceTemplate":                 "%s-service.yaml",
+	"ServiceAccountTemplate":          "%s-serviceaccount.yaml",
+	"HorizontalPodAutoscalerTemplate": "%s-hpa.yaml",
+	"ScaledObjectTemplate":            "%s-scaledobject.yaml",
+	"PodDisruptionBudgetTemplate":     "%s-pdb.yaml",
+	"JobTemplate":                     "%s-job.yaml",
+}
+
+// TemplateData is handed to text templates, the deployable fields are promoted so .Artifact.Name keeps working
+type TemplateData struct {
+	*model.Deployable
+	Globals map[string]string
+	Task    model.Task
+	Context environment.Context
+}
+
+// IsBuiltin reports whether the template name is generated by shores itself
+func IsBuiltin(tName string) bool {
+	_, ok := templateFiles[tName]
+	return ok || tName == "ChartTemplate"
+}
+
+// AdditionalTemplates returns the custom templates that add files for the app kind, sorted by name
+func AdditionalTemplates(custom map[string]customtemplate.TemplateSpec, kind string) []customtemplate.TemplateSpec {
+	additional := make([]customtemplate.TemplateSpec, 0)
+	for name, spec := range custom {
+		if !IsBuiltin(name) && spec.AppliesTo(kind) {
+			additional = append(additional, spec)
+		}
+	}
+	sort.Slice(additional, func(i, j int) bool {
+		return additional[i].Name < additional[j].Name
+	})
+	return additional
+}
+
+// OutputFile is the name of the file generated for the template
+func OutputFile(tName string, deployable *model.Deployable) string {
+	if tName == "ChartTemplate" {
+		return "Chart.yaml"
+	}
+	return fmt.Sprintf(templateFiles[tName], deployable.Artifact.Name)
+}
+
+// Render produces the manifest for the template name, the typed object is marshalled unless the text renderer is chosen
+func Render(tName string, data TemplateData, dataMaps map[string]map[string]string, renderer string) ([]byte, error) {
+	switch renderer {
+	case "", RendererTyped:
+		return renderTyped(tName, data)
+	case RendererText:
+		if data.Task.Mode == ModeValues {
+			return nil, errors.New(fmt.Sprintf("the %s mode needs the %s renderer", ModeValues, RendererTyped))
+		}
+		return renderText(tName, data, dataMaps)
+	}
+	return nil, errors.New(fmt.Sprintf("unknown renderer [%s], expected %s or %s", renderer, RendererTyped, RendererText))
+}
+
+func renderTyped(tName string, data TemplateData) ([]byte, error) {
+	object, err := BuildObject(tName, data.Deployable)
+	if err != nil {
+		return nil, err
+	}
+	content, err := yaml.Marshal(object)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("template: [%s], error marshalling %T: [%v]", tName, object, err))
+	}
+	if tName == "ChartTemplate" {
+		return content, nil
+	}
+	switch data.Task.Mode {
+	case ModeValues:
+		return parameterise(tName, content)
+	case ModeManifests, ModeKustomize:
+		return content, nil
+	}
+	return escapeHelm(content), nil
+}
+
+func renderText(tName string, data TemplateData, dataMaps map[string]map[string]string) ([]byte, error) {
+	tmpl, err := LoadTemplates(tName, data.Deployable, TemplateFuncs(dataMaps, data.Globals))
+	if err != nil {
+		return nil, err
+	}
+	if tmpl == nil {
+		return nil, errors.New(fmt.Sprintf("no text template named [%s]", tName))
+	}
+	return execute(tmpl, data)
+}
+
+// RenderCustom executes a template supplied under the provider templates directory
+func RenderCustom(custom customtemplate.TemplateSpec, data TemplateData, dataMaps map[string]map[string]string) ([]byte, error) {
+	tmpl, err := getTemplate(custom.File, custom.Text, TemplateFuncs(dataMaps, data.Globals))
+	if err != nil {
+		return nil, err
+	}
+	return execute(tmpl, data)
+}
+
+func execute(tmpl *template.Template, data TemplateData) ([]byte, error) {
+	out := bytes.Buffer{}
+	if exErr := tmpl.Execute(&out, data); exErr != nil {
+		return nil, exErr
+	}
+	return out.Bytes(), nil
+}
+
+// escapeHelm keeps helm from evaluating {{ inside generated values, the files under templates/ are helm templates
+func escapeHelm(content []byte) []byte {
+	return []byte(strings.ReplaceAll(string(content), "{{", `{{ "{{" }}`))
+}
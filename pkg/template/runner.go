@@ -5,12 +5,24 @@ import (
 	"errors"
 	"fmt"
 	"github.com/skhatri/shores/pkg/applog"
+	"github.com/skhatri/shores/pkg/chartrepo"
+	"github.com/skhatri/shores/pkg/customtemplate"
+	"github.com/skhatri/shores/pkg/datamap"
+	"github.com/skhatri/shores/pkg/dataref"
+	"github.com/skhatri/shores/pkg/envdefinition"
+	"github.com/skhatri/shores/pkg/environment"
 	"github.com/skhatri/shores/pkg/functions"
 	"github.com/skhatri/shores/pkg/glb"
+	"github.com/skhatri/shores/pkg/location"
 	"github.com/skhatri/shores/pkg/mixin"
 	model "github.com/skhatri/shores/pkg/model"
+	"github.com/skhatri/shores/pkg/nodepool"
 	"github.com/skhatri/shores/pkg/preprocess"
 	"github.com/skhatri/shores/pkg/resource"
+	"github.com/skhatri/shores/pkg/scaling"
+	"github.com/skhatri/shores/pkg/stack"
+	"github.com/skhatri/shores/pkg/team"
+	"gopkg.in/yaml.v2"
 	"os"
 	"path/filepath"
 	"strings"
@@ -27,69 +39,252 @@ func createDirSafely(fileName string) error {
 	return nil
 }
 
-func Run(productSet *model.ProductSet, task model.Task) (*model.DeploymentSummary, error) {
+func loadLookupData(ctx environment.Context) preprocess.LookupData {
+	globalEnvData := glb.LoadVars(functions.ListFiles("spec/provider/globals", ".yaml"), ctx)
+	dataMaps := datamap.LoadDataMaps(functions.ListFiles("spec/provider/data", ".yaml"))
+	dataRefs := dataref.LoadDataRefs(functions.ListFiles("spec/provider/data-ref", ".yaml"), functions.ListFiles("spec/provider/infrastructure", ".yaml"), ctx)
+	envData := glb.LoadVarsWithSubstitution(functions.ListFiles("spec/provider/env-sets", ".yaml"), globalEnvData, dataMaps, dataRefs, ctx)
 
-	globalEnvData := glb.LoadVars(functions.ListFiles("spec/provider/globals", ".yaml"))
-	envData := glb.LoadVarsWithSubstitution(functions.ListFiles("spec/provider/env-sets", ".yaml"), globalEnvData)
+	resourcesData := resource.LoadResources(functions.ListFiles("spec/provider/resources", ".yaml"), ctx)
+	mixinData := mixin.LoadMixins(functions.ListFiles("spec/provider/mixins", ".yaml"), ctx)
+	stackData := stack.LoadStacks(functions.ListFiles("spec/templates", ".yaml"))
+	for name, stackTemplate := range stackData {
+		if _, exists := mixinData[name]; exists {
+			applog.Tag("load-stacks").WithAttribute("stack", name).Error("stack %s is shadowed by a mixin of the same name", name)
+			continue
+		}
+		mixinData[name] = stackTemplate
+	}
+	return preprocess.LookupData{
+		Env:       envData,
+		Resources: resourcesData,
+		Mixins:    mixinData,
+		DataMaps:  dataMaps,
+		Globals:   globalEnvData,
+
+		ScalingGroups: scaling.LoadScalingGroups(functions.ListFiles("spec/provider/scaling-groups", ".yaml")),
+		NodePools:     nodepool.LoadNodePools(functions.ListFiles("spec/provider/node-pools", ".yaml"), ctx),
+		Teams:         team.LoadTeams(functions.ListFiles("spec/provider/teams", ".yaml")),
+
+		Context: ctx,
+	}
+}
+
+func loadCatalog() environment.Catalog {
+	return environment.Catalog{
+		Locations:    location.LoadLocations(functions.ListFiles("spec/provider/locations", ".yaml")),
+		Environments: envdefinition.LoadDefinitions(functions.ListFiles("spec/provider/environments", ".yaml")),
+	}
+}
+
+// ProcessContext resolves ENV_NAME, LOCATION and CLUSTER against the declared locations and environments
+func ProcessContext() (environment.Context, error) {
+	return environment.FromProcess(loadCatalog())
+}
+
+func resolveContext(contextSpec *model.ContextSpec, catalog environment.Catalog) (environment.Context, error) {
+	return environment.NewContext(contextSpec.EnvName, contextSpec.Location, contextSpec.Cluster, contextSpec.Labels, catalog)
+}
+
+func prepareApp(app *model.ReleaseSpec, lookup preprocess.LookupData, task model.Task) (*model.Deployable, error) {
+	appSpec := model.AppSpec{}
+	uerr := functions.UnmarshalFile(fmt.Sprintf("spec/user/apps/%s.yaml", app.Name), &appSpec)
+	if uerr != nil {
+		return nil, uerr
+	}
+	deployable, err := preprocess.ValidateAppSpec(appSpec, lookup, *app, task)
+	if err != nil {
+		applog.Tag("deployer").WithAttribute("app_name", app.Name).Error("error %v", err)
+		return nil, err
+	}
+	if applog.IsDebugEnabled() {
+		b, e := json.Marshal(deployable)
+		if e != nil {
+			applog.Tag("marshaller").Error("error marshalling json", e)
+		}
+		fmt.Fprintln(applog.Output, string(b))
+	}
+	return deployable, nil
+}
+
+// Prepare validates every app of the product set for the context without writing any output
+func Prepare(productSet *model.ProductSet, task model.Task, ctx environment.Context) ([]*model.Deployable, error) {
+	lookup := loadLookupData(ctx)
+	deployables := make([]*model.Deployable, 0)
+	for _, app := range productSet.Apps {
+		deployable, err := prepareApp(app, lookup, task)
+		if err != nil {
+			return nil, err
+		}
+		deployables = append(deployables, deployable)
+	}
+	return deployables, nil
+}
 
-	resourcesData := resource.LoadResources(functions.ListFiles("spec/provider/resources", ".yaml"))
-	mixinData := mixin.LoadMixins(functions.ListFiles("spec/provider/mixins", ".yaml"))
+// RunMatrix renders the release for every context of the matrix into an output subdirectory named after the context
+func RunMatrix(productSet *model.ProductSet, task model.Task) (*model.DeploymentSummary, error) {
+	if len(productSet.Matrix) == 0 {
+		return nil, errors.New(fmt.Sprintf("release [%s] has no matrix to render", productSet.Name))
+	}
+	if task.Output == model.StdoutOutput {
+		return nil, errors.New("a matrix renders into a directory per context and cannot stream to stdout")
+	}
+	if task.Mode == ModeKustomize {
+		return nil, errors.New(fmt.Sprintf("the %s mode renders its overlays in one layout, use overlays instead of a matrix", ModeKustomize))
+	}
+	summary := model.DeploymentSummary{
+		Namespace: *productSet.Namespace,
+		Items:     make([]model.DeploymentItem, 0),
+	}
+	catalog := loadCatalog()
+	seen := make(map[string]bool)
+	for _, contextSpec := range productSet.Matrix {
+		if contextSpec.Name == "" || seen[contextSpec.Name] {
+			return nil, errors.New(fmt.Sprintf("matrix entry [%s] needs a unique name, env, location or cluster", contextSpec.Name))
+		}
+		seen[contextSpec.Name] = true
+		ctx, cerr := resolveContext(contextSpec, catalog)
+		if cerr != nil {
+			return nil, errors.New(fmt.Sprintf("context: [%s], error: [%v]", contextSpec.Name, cerr))
+		}
+		contextTask := task
+		contextTask.Output = fmt.Sprintf("%s/%s", task.Output, contextSpec.Name)
+		if task.ChartRepo != "" {
+			contextTask.ChartRepo = fmt.Sprintf("%s/%s", task.ChartRepo, contextSpec.Name)
+		}
+		applog.Tag("matrix").WithAttribute("context", contextSpec.Name).Info("Rendering release")
+		contextSummary, err := Run(productSet, contextTask, ctx)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("context: [%s], error: [%v]", contextSpec.Name, err))
+		}
+		summary.Items = append(summary.Items, contextSummary.Items...)
+	}
+	return &summary, nil
+}
 
+// Run renders the release for the context
+func Run(productSet *model.ProductSet, task model.Task, ctx environment.Context) (*model.DeploymentSummary, error) {
+	if task.Mode != "" && task.Mode != ModeRendered && task.Mode != ModeValues && task.Mode != ModeManifests && task.Mode != ModeKustomize {
+		return nil, errors.New(fmt.Sprintf("unknown mode [%s], expected %s, %s, %s or %s", task.Mode, ModeRendered, ModeValues, ModeManifests, ModeKustomize))
+	}
+	if task.Mode == ModeKustomize {
+		return runKustomize(productSet, task, ctx)
+	}
+	if task.Mode == ModeManifests && task.ChartRepo != "" {
+		return nil, errors.New(fmt.Sprintf("the %s mode produces no charts to package", ModeManifests))
+	}
+	lookup := loadLookupData(ctx)
+	customTemplates := customtemplate.LoadTemplates(functions.ListFiles("spec/provider/templates", ".yaml"))
+	chartVersioning := ""
+	if productSet.ChartVersioning != nil {
+		chartVersioning = *productSet.ChartVersioning
+	}
+
+	var repo *chartRepository
+	if task.ChartRepo != "" {
+		var rerr error
+		repo, rerr = openChartRepository(task.ChartRepo, task.Created)
+		if rerr != nil {
+			return nil, errors.New(fmt.Sprintf("task: chart-repo, error: [%v]", rerr))
+		}
+	}
 	itemSummary := model.DeploymentSummary{}
 	items := make([]model.DeploymentItem, 0)
 	for _, app := range productSet.Apps {
-		appSpec := model.AppSpec{}
-		uerr := functions.UnmarshalFile(fmt.Sprintf("spec/user/apps/%s.yaml", app.Name), &appSpec)
-		if uerr != nil {
-			return nil, uerr
-		}
 		applog.Tag("generator").WithAttribute("app_name", app.Name).Info("Generating app")
-		deployable, err := preprocess.ValidateAppSpec(appSpec, envData, resourcesData, mixinData, *app, task)
+		deployable, err := prepareApp(app, lookup, task)
 		if err != nil {
-			applog.Tag("deployer").WithAttribute("app_name", app.Name).Error("error %v", err)
-			break
-		}
-		if applog.IsDebugEnabled() {
-			b, e := json.Marshal(deployable)
-			if e != nil {
-				applog.Tag("marshaller").Error("error marshalling json", e)
-			}
-			fmt.Println(string(b))
+			return nil, err
 		}
 		outputDir := task.Output
 		appWorkDir := fmt.Sprintf("%s/%s/", outputDir, app.Name)
 		appTemplatesDir := fmt.Sprintf("%s/%s/templates/", outputDir, app.Name)
 
+		files, kind, err := renderApp(deployable, lookup, task, customTemplates)
+		if err != nil {
+			return nil, err
+		}
+		if task.Mode == ModeManifests {
+			manifestPath, merr := writeManifests(task.Output, app.Name, files)
+			if merr != nil {
+				return nil, errors.New(fmt.Sprintf("task: manifests, app: [%s], error: [%v]", app.Name, merr))
+			}
+			items = append(items, model.DeploymentItem{
+				Name: app.Name,
+				Kind: kind,
+				Path: manifestPath,
+				Qos:  deployable.QosClass,
+			})
+			continue
+		}
+
 		cerr := createDirSafely(appTemplatesDir)
 		if cerr != nil {
 			return nil, cerr
 		}
-		requiredTemplates, kind := GetRequiredTemplates(deployable)
-		for _, tName := range requiredTemplates {
-			tmpl, err := LoadTemplates(tName, deployable)
-			if err != nil {
-				return nil, errors.New(fmt.Sprintf("task: load-template, app: [%s], error: [%v]", app.Name, err))
-			}
-			targetDir := appTemplatesDir
-			if tName == "ChartTemplate" {
-				targetDir = appWorkDir
-			}
-			file, er := os.Create(fmt.Sprintf("%s%s", targetDir, tmpl.Name()))
-			if er != nil {
-				return nil, er
+		hash := contentHash(files)
+		version, verr := ChartVersion(chartVersioning, deployable.Chart.AppVersion, task.ReleaseId, hash, readChart(appWorkDir+"Chart.yaml"))
+		if verr != nil {
+			return nil, errors.New(fmt.Sprintf("task: chart-version, app: [%s], error: [%v]", app.Name, verr))
+		}
+		deployable.Chart.Version = version
+		deployable.Chart.ContentHash = hash
+		deployable.Metadata.Labels["helm.sh/chart"] = chartLabel(deployable.Artifact.Name, version)
+		files, _, err = renderApp(deployable, lookup, task, customTemplates)
+		if err != nil {
+			return nil, err
+		}
+		data := TemplateData{
+			Deployable: deployable,
+			Globals:    lookup.Globals,
+			Task:       task,
+			Context:    lookup.Context,
+		}
+		chart, err := renderTemplate("ChartTemplate", kind, customTemplates, data, lookup.DataMaps)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("task: render, template: [ChartTemplate], app: [%s], error: [%v]", app.Name, err))
+		}
+		files = append(files, chartrepo.File{Path: "Chart.yaml", Content: chart})
+		for _, file := range files {
+			werr := os.WriteFile(appWorkDir+file.Path, file.Content, 0644)
+			if werr != nil {
+				return nil, werr
 			}
-
-			exErr := tmpl.Execute(file, &deployable)
-			if exErr != nil {
-				return nil, errors.New(fmt.Sprintf("task: execute, template: [%s], app: [%s], error: [%v]", tName, app.Name, err))
+		}
+		if repo != nil {
+			perr := repo.add(files)
+			if perr != nil {
+				return nil, errors.New(fmt.Sprintf("task: package, app: [%s], error: [%v]", app.Name, perr))
 			}
 		}
 		items = append(items, model.DeploymentItem{
 			Name: app.Name,
 			Kind: kind,
 			Path: appWorkDir,
+			Qos:  deployable.QosClass,
+
+			ChartVersion: version,
 		})
 	}
+	if productSet.Umbrella != nil && task.Mode != ModeManifests {
+		umbrella, umbrellaFiles, uerr := writeUmbrella(productSet, task, chartVersioning, items)
+		if uerr != nil {
+			return nil, errors.New(fmt.Sprintf("task: umbrella, release: [%s], error: [%v]", productSet.Name, uerr))
+		}
+		items = append(items, *umbrella)
+		if repo != nil {
+			perr := repo.add(umbrellaFiles)
+			if perr != nil {
+				return nil, errors.New(fmt.Sprintf("task: package, release: [%s], error: [%v]", productSet.Name, perr))
+			}
+		}
+	}
+	if repo != nil {
+		ierr := repo.writeIndex()
+		if ierr != nil {
+			return nil, errors.New(fmt.Sprintf("task: chart-repo, error: [%v]", ierr))
+		}
+	}
 	itemSummary = model.DeploymentSummary{
 		Namespace: *productSet.Namespace,
 		Items:     items,
@@ -97,6 +292,75 @@ func Run(productSet *model.ProductSet, task model.Task) (*model.DeploymentSummar
 	return &itemSummary, nil
 }
 
+// renderApp renders the manifests of the app except Chart.yaml, paths are relative to the chart directory
+func renderApp(deployable *model.Deployable, lookup preprocess.LookupData, task model.Task, customTemplates map[string]customtemplate.TemplateSpec) ([]chartrepo.File, string, error) {
+	appName := deployable.Artifact.Name
+	requiredTemplates, kind := GetRequiredTemplates(deployable)
+	data := TemplateData{
+		Deployable: deployable,
+		Globals:    lookup.Globals,
+		Task:       task,
+		Context:    lookup.Context,
+	}
+	files := make([]chartrepo.File, 0)
+	for _, tName := range requiredTemplates {
+		if tName == "ChartTemplate" {
+			continue
+		}
+		content, err := renderTemplate(tName, kind, customTemplates, data, lookup.DataMaps)
+		if err != nil {
+			return nil, kind, errors.New(fmt.Sprintf("task: render, template: [%s], app: [%s], error: [%v]", tName, appName, err))
+		}
+		files = append(files, chartrepo.File{Path: fmt.Sprintf("templates/%s", OutputFile(tName, deployable)), Content: content})
+	}
+	if task.Mode == ModeValues {
+		valuesFiles, verr := valuesFiles(deployable)
+		if verr != nil {
+			return nil, kind, errors.New(fmt.Sprintf("task: values, app: [%s], error: [%v]", appName, verr))
+		}
+		files = append(files, valuesFiles...)
+	}
+	for _, custom := range AdditionalTemplates(customTemplates, kind) {
+		content, err := RenderCustom(custom, data, lookup.DataMaps)
+		if err != nil {
+			return nil, kind, errors.New(fmt.Sprintf("task: render, template: [%s], app: [%s], error: [%v]", custom.Name, appName, err))
+		}
+		files = append(files, chartrepo.File{Path: fmt.Sprintf("templates/%s", fmt.Sprintf(custom.Output, appName)), Content: content})
+	}
+	return files, kind, nil
+}
+
+// renderTemplate prefers a custom override of the template, overrides are plain go templates and cannot be parameterised in values mode
+func renderTemplate(tName string, kind string, customTemplates map[string]customtemplate.TemplateSpec, data TemplateData, dataMaps map[string]map[string]string) ([]byte, error) {
+	if custom, ok := customTemplates[tName]; ok && custom.AppliesTo(kind) {
+		if data.Task.Mode == ModeValues && tName != "ChartTemplate" {
+			return nil, errors.New(fmt.Sprintf("custom template [%s] overrides a built-in template, which the %s mode cannot parameterise", custom.File, ModeValues))
+		}
+		return RenderCustom(custom, data, dataMaps)
+	}
+	return Render(tName, data, dataMaps, data.Task.Renderer)
+}
+
+func valuesFiles(deployable *model.Deployable) ([]chartrepo.File, error) {
+	content, err := yaml.Marshal(BuildValues(deployable))
+	if err != nil {
+		return nil, err
+	}
+	files := []chartrepo.File{{Path: "values.yaml", Content: content}}
+	if deployable.ServiceEnabled {
+		files = append(files, chartrepo.File{
+			Path:    fmt.Sprintf("templates/%s-ingress.yaml", deployable.Artifact.Name),
+			Content: IngressTemplate(deployable),
+		})
+	}
+	return files, nil
+}
+
+// chartLabel is the helm.sh/chart value helm itself sets, a + of the version is not allowed in label values
+func chartLabel(name string, version string) string {
+	return strings.ReplaceAll(fmt.Sprintf("%s-%s", name, version), "+", "_")
+}
+
 func GetRequiredTemplates(deployable *model.Deployable) ([]string, string) {
 	kind := ""
 	requiredTemplates := make([]string, 0)
@@ -104,9 +368,19 @@ func GetRequiredTemplates(deployable *model.Deployable) ([]string, string) {
 	if deployable.ServiceAccountName == nil {
 		requiredTemplates = append(requiredTemplates, "ServiceAccountTemplate")
 	}
-	if len(deployable.Kind) == 0 || deployable.Kind == "Deployment" {
+	if len(deployable.Kind) == 0 || strings.EqualFold(deployable.Kind, "Deployment") {
 		requiredTemplates = append(requiredTemplates, "DeploymentTemplate")
 		kind = "deployment"
+		if deployable.Autoscaling != nil {
+			if deployable.Autoscaling.EventDriven() {
+				requiredTemplates = append(requiredTemplates, "ScaledObjectTemplate")
+			} else {
+				requiredTemplates = append(requiredTemplates, "HorizontalPodAutoscalerTemplate")
+			}
+		}
+		if deployable.DisruptionBudget != nil {
+			requiredTemplates = append(requiredTemplates, "PodDisruptionBudgetTemplate")
+		}
 	} else if strings.EqualFold(deployable.Kind, "Job") {
 		requiredTemplates = append(requiredTemplates, "JobTemplate")
 		kind = "job"
@@ -0,0 +1,47 @@
+package templates
+
+import (
+	"github.com/skhatri/shores/pkg/customtemplate"
+	"github.com/skhatri/shores/pkg/functions"
+	"github.com/skhatri/shores/pkg/model"
+	"reflect"
+	"testing"
+)
+
+func TestTemplatesFollowTheAppKind(t *testing.T) {
+	customTemplates := customtemplate.LoadTemplates(functions.ListFiles("testdata/templates", ".yaml"))
+	tests := []struct {
+		name       string
+		kind       string
+		workload   string
+		additional []string
+	}{
+		{"deployment skips job templates", "Deployment", "DeploymentTemplate", []string{"PodNoteTemplate"}},
+		{"missing kind is a deployment", "", "DeploymentTemplate", []string{"PodNoteTemplate"}},
+		{"job gets job templates", "Job", "JobTemplate", []string{"JobReportTemplate", "PodNoteTemplate"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			required, kind := GetRequiredTemplates(&model.Deployable{Kind: test.kind})
+			if !contains(required, test.workload) {
+				t.Errorf("required templates %v have no %s", required, test.workload)
+			}
+			additional := make([]string, 0)
+			for _, custom := range AdditionalTemplates(customTemplates, kind) {
+				additional = append(additional, custom.Name)
+			}
+			if !reflect.DeepEqual(additional, test.additional) {
+				t.Errorf("additional templates for %s = %v, want %v", kind, additional, test.additional)
+			}
+		})
+	}
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
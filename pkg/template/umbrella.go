@@ -0,0 +1,117 @@
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"github.com/skhatri/shores/pkg/chartrepo"
+	"github.com/skhatri/shores/pkg/k8s"
+	"github.com/skhatri/shores/pkg/model"
+	"gopkg.in/yaml.v2"
+	"os"
+)
+
+const defaultUmbrellaVersion = "0.1.0"
+
+// UmbrellaValues toggles the app charts of the release set, each app is keyed by its chart name
+type UmbrellaValues struct {
+	Namespace NamespaceValues      `json:"namespace" yaml:"namespace"`
+	Apps      map[string]AppToggle `json:",inline" yaml:",inline"`
+}
+
+type NamespaceValues struct {
+	Name   string `json:"name" yaml:"name"`
+	Create bool   `json:"create" yaml:"create"`
+}
+
+type AppToggle struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+}
+
+var namespaceTemplate = `{{- if .Values.namespace.create }}
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: {{ .Values.namespace.name }}
+{{- end }}
+`
+
+// writeUmbrella generates a chart for the release set that depends on every generated app chart
+func writeUmbrella(productSet *model.ProductSet, task model.Task, chartVersioning string, items []model.DeploymentItem) (*model.DeploymentItem, []chartrepo.File, error) {
+	name := productSet.Name
+	if productSet.Umbrella.Name != nil {
+		name = *productSet.Umbrella.Name
+	}
+	values := UmbrellaValues{
+		Namespace: NamespaceValues{Name: *productSet.Namespace},
+		Apps:      make(map[string]AppToggle, 0),
+	}
+	chart := k8s.Chart{
+		ApiVersion:  "v2",
+		Name:        name,
+		Description: fmt.Sprintf("Umbrella chart for the %s release set", productSet.Name),
+		Type:        "application",
+	}
+	for _, item := range items {
+		if item.Name == name {
+			return nil, nil, errors.New(fmt.Sprintf("umbrella chart %s has the same name as an app", name))
+		}
+		if item.Name == "namespace" {
+			return nil, nil, errors.New("an app named namespace clashes with the namespace entry of the umbrella values")
+		}
+		chart.Dependencies = append(chart.Dependencies, k8s.ChartDependency{
+			Name:       item.Name,
+			Version:    item.ChartVersion,
+			Repository: fmt.Sprintf("file://../%s", item.Name),
+			Condition:  fmt.Sprintf("%s.enabled", item.Name),
+		})
+		values.Apps[item.Name] = AppToggle{Enabled: true}
+	}
+	valuesContent, err := yaml.Marshal(values)
+	if err != nil {
+		return nil, nil, err
+	}
+	dependencies, err := yaml.Marshal(chart.Dependencies)
+	if err != nil {
+		return nil, nil, err
+	}
+	files := []chartrepo.File{
+		{Path: "values.yaml", Content: valuesContent},
+		{Path: "templates/namespace.yaml", Content: []byte(namespaceTemplate)},
+	}
+	hash := contentHash(append(files, chartrepo.File{Path: "dependencies", Content: dependencies}))
+
+	umbrellaDir := fmt.Sprintf("%s/%s/", task.Output, name)
+	baseVersion := defaultUmbrellaVersion
+	if productSet.Umbrella.Version != nil {
+		baseVersion = *productSet.Umbrella.Version
+	}
+	version, verr := ChartVersion(chartVersioning, baseVersion, task.ReleaseId, hash, readChart(umbrellaDir+"Chart.yaml"))
+	if verr != nil {
+		return nil, nil, verr
+	}
+	chart.Version = version
+	chart.AppVersion = version
+	chart.Annotations = map[string]string{contentHashAnnotation: hash}
+	chartContent, err := yaml.Marshal(chart)
+	if err != nil {
+		return nil, nil, err
+	}
+	files = append(files, chartrepo.File{Path: "Chart.yaml", Content: chartContent})
+
+	cerr := createDirSafely(umbrellaDir + "templates/")
+	if cerr != nil {
+		return nil, nil, cerr
+	}
+	for _, file := range files {
+		werr := os.WriteFile(umbrellaDir+file.Path, file.Content, 0644)
+		if werr != nil {
+			return nil, nil, werr
+		}
+	}
+	return &model.DeploymentItem{
+		Name:         name,
+		Kind:         "umbrella",
+		Path:         umbrellaDir,
+		ChartVersion: version,
+	}, files, nil
+}
@@ -0,0 +1,232 @@
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"github.com/skhatri/shores/pkg/model"
+	"gopkg.in/yaml.v2"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	ModeRendered = "rendered"
+	ModeValues   = "values"
+	// ModeManifests writes plain kubernetes manifests without chart files or helm labels
+	ModeManifests = model.ModeManifests
+	ModeKustomize = model.ModeKustomize
+)
+
+// Values is the values.yaml of a chart generated in values mode, it holds the defaults shores resolved for the app
+type Values struct {
+	Image        ImageValues                  `json:"image" yaml:"image"`
+	ReplicaCount *int                         `json:"replicaCount,omitempty" yaml:"replicaCount,omitempty"`
+	Resources    map[string]map[string]string `json:"resources" yaml:"resources"`
+	Env          map[string]string            `json:"env" yaml:"env"`
+	Ingress      IngressValues                `json:"ingress" yaml:"ingress"`
+}
+
+type ImageValues struct {
+	Repository string `json:"repository" yaml:"repository"`
+	Tag        string `json:"tag" yaml:"tag"`
+	PullPolicy string `json:"pullPolicy" yaml:"pullPolicy"`
+}
+
+type IngressValues struct {
+	Enabled   bool     `json:"enabled" yaml:"enabled"`
+	ClassName string   `json:"className" yaml:"className"`
+	Hosts     []string `json:"hosts" yaml:"hosts"`
+}
+
+// helmValue stands in for a helm expression while the manifest is marshalled, block values span several lines
+type helmValue struct {
+	path  []string
+	value func(indent int) string
+	block bool
+}
+
+var helmPlaceholder = regexp.MustCompile(`(?m)^(\s*)(- )?([\w.-]+): __helm_(\d+)__$`)
+
+func scalarValue(expression string, path ...string) helmValue {
+	return helmValue{path: path, value: func(int) string { return expression }}
+}
+
+func blockValue(value func(indent int) string, path ...string) helmValue {
+	return helmValue{path: path, value: value, block: true}
+}
+
+// BuildValues collects the values.yaml defaults for the deployable
+func BuildValues(deployable *model.Deployable) Values {
+	repository, tag := splitImage(deployable.Artifact.Image)
+	values := Values{
+		Image: ImageValues{
+			Repository: repository,
+			Tag:        tag,
+			PullPolicy: "IfNotPresent",
+		},
+		Resources: make(map[string]map[string]string, 0),
+		Env:       make(map[string]string, 0),
+		Ingress: IngressValues{
+			Hosts: make([]string, 0),
+		},
+	}
+	if deployable.Autoscaling == nil {
+		replicas := deployable.Target.Replica
+		values.ReplicaCount = &replicas
+	}
+	if deployable.Resources != nil {
+		if requests := resourceValues(deployable.Resources.Requests); len(requests) > 0 {
+			values.Resources["requests"] = requests
+		}
+		if limits := resourceValues(deployable.Resources.Limits); len(limits) > 0 {
+			values.Resources["limits"] = limits
+		}
+	}
+	for _, env := range buildEnv(deployable.Env) {
+		values.Env[env.Name] = env.Value
+	}
+	if ingress := deployable.Ingress; ingress != nil {
+		values.Ingress.ClassName = ingress.Group
+		values.Ingress.Hosts = append(values.Ingress.Hosts, ingress.Hosts...)
+		values.Ingress.Enabled = len(values.Ingress.Hosts) > 0
+	}
+	return values
+}
+
+// splitImage separates the tag from the repository, a colon before the last slash belongs to a registry port
+func splitImage(image string) (string, string) {
+	colon := strings.LastIndex(image, ":")
+	if colon == -1 || colon < strings.LastIndex(image, "/") {
+		return image, "latest"
+	}
+	return image[:colon], image[colon+1:]
+}
+
+func containerValues(prefix ...string) []helmValue {
+	at := func(path ...string) []string {
+		return append(append([]string{}, prefix...), path...)
+	}
+	return []helmValue{
+		scalarValue(`"{{ .Values.image.repository }}:{{ .Values.image.tag }}"`, at("image")...),
+		scalarValue("{{ .Values.image.pullPolicy }}", at("imagePullPolicy")...),
+		blockValue(func(indent int) string {
+			return fmt.Sprintf("%s{{- toYaml .Values.resources | nindent %d }}", strings.Repeat(" ", indent), indent)
+		}, at("resources")...),
+		blockValue(func(indent int) string {
+			pad := strings.Repeat(" ", indent)
+			return pad + "{{- range $name, $value := .Values.env }}\n" +
+				pad + "- name: {{ $name }}\n" +
+				pad + "  value: {{ $value | quote }}\n" +
+				pad + "{{- end }}"
+		}, at("env")...),
+	}
+}
+
+func helmValues(tName string) []helmValue {
+	switch tName {
+	case "DeploymentTemplate":
+		return append(containerValues("spec", "template", "spec", "containers", "0"),
+			scalarValue("{{ .Values.replicaCount }}", "spec", "replicas"))
+	case "JobTemplate":
+		return containerValues("spec", "template", "spec", "containers", "0")
+	}
+	return nil
+}
+
+// parameterise swaps the values owned by values.yaml for helm expressions in a marshalled manifest
+func parameterise(tName string, content []byte) ([]byte, error) {
+	expressions := helmValues(tName)
+	if len(expressions) == 0 {
+		return content, nil
+	}
+	document := yaml.MapSlice{}
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+	for i, expression := range expressions {
+		setPath(document, expression.path, fmt.Sprintf("__helm_%d__", i))
+	}
+	marshalled, err := yaml.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+	var replaceErr error
+	result := helmPlaceholder.ReplaceAllStringFunc(string(escapeHelm(marshalled)), func(line string) string {
+		match := helmPlaceholder.FindStringSubmatch(line)
+		index, _ := strconv.Atoi(match[4])
+		expression := expressions[index]
+		if !expression.block {
+			return fmt.Sprintf("%s%s%s: %s", match[1], match[2], match[3], expression.value(0))
+		}
+		keyIndent := len(match[1]) + len(match[2])
+		return fmt.Sprintf("%s%s%s:\n%s", match[1], match[2], match[3], expression.value(keyIndent+2))
+	})
+	if strings.Contains(result, "__helm_") {
+		replaceErr = errors.New(fmt.Sprintf("template: [%s], a values placeholder was not replaced", tName))
+	}
+	return []byte(result), replaceErr
+}
+
+// setPath replaces the value at the path when it exists, numeric path elements index into lists
+func setPath(node interface{}, path []string, value interface{}) bool {
+	switch current := node.(type) {
+	case yaml.MapSlice:
+		for i := range current {
+			if fmt.Sprint(current[i].Key) != path[0] {
+				continue
+			}
+			if len(path) == 1 {
+				current[i].Value = value
+				return true
+			}
+			return setPath(current[i].Value, path[1:], value)
+		}
+	case []interface{}:
+		index, err := strconv.Atoi(path[0])
+		if err != nil || index >= len(current) {
+			return false
+		}
+		if len(path) == 1 {
+			current[index] = value
+			return true
+		}
+		return setPath(current[index], path[1:], value)
+	}
+	return false
+}
+
+var ingressTemplate = `{{- if .Values.ingress.enabled }}
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: %[1]s
+  namespace: %[2]s
+spec:
+  {{- with .Values.ingress.className }}
+  ingressClassName: {{ . }}
+  {{- end }}
+  rules:
+    {{- range $host := .Values.ingress.hosts }}
+    - host: {{ $host | quote }}
+      http:
+        paths:
+          - path: /
+            pathType: Prefix
+            backend:
+              service:
+                name: %[1]s
+                port:
+                  name: %[3]s
+    {{- end }}
+{{- end }}
+`
+
+// IngressTemplate is the helm template generated next to values.yaml for apps exposing a service
+func IngressTemplate(deployable *model.Deployable) []byte {
+	portName := "http"
+	if ports := deployable.Ports(); len(ports) > 0 {
+		portName = ports[0].Name
+	}
+	return []byte(fmt.Sprintf(ingressTemplate, objectName(deployable), deployable.Namespace, portName))
+}